@@ -0,0 +1,488 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lynn-Xy/bloggatog/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const pqUniqueViolation = "23505"
+
+type apiHandler func(s *state, w http.ResponseWriter, r *http.Request, user database.User)
+
+type apiUser struct {
+	ID uuid.UUID `json:"id"`
+	Name string `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type apiFeed struct {
+	ID uuid.UUID `json:"id"`
+	Name string `json:"name"`
+	Url string `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type apiFollow struct {
+	Name string `json:"name"`
+	Url string `json:"url"`
+	SiteUrl *string `json:"site_url"`
+	Folder *string `json:"folder"`
+}
+
+type apiPost struct {
+	ID uuid.UUID `json:"id"`
+	FeedID uuid.UUID `json:"feed_id"`
+	Title string `json:"title"`
+	Url string `json:"url"`
+	Description string `json:"description"`
+	Content *string `json:"content"`
+	Author *string `json:"author"`
+	Categories []string `json:"categories"`
+	PublishedAt *time.Time `json:"published_at"`
+	ReadAt *time.Time `json:"read_at"`
+	StarredAt *time.Time `json:"starred_at"`
+	EditedAt *time.Time `json:"edited_at"`
+	Muted bool `json:"muted"`
+}
+
+type apiFeedStatus struct {
+	ID uuid.UUID `json:"id"`
+	Name string `json:"name"`
+	Url string `json:"url"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+	NextFetchAt *time.Time `json:"next_fetch_at"`
+	LastStatus *int32 `json:"last_status"`
+	LastError *string `json:"last_error"`
+	ConsecutiveFailures int32 `json:"consecutive_failures"`
+	DisabledAt *time.Time `json:"disabled_at"`
+}
+
+type apiStatus struct {
+	Feeds int `json:"feeds"`
+	Healthy int `json:"healthy"`
+	Failing int `json:"failing"`
+	Disabled int `json:"disabled"`
+	NextFetchAt *time.Time `json:"next_fetch_at"`
+	FeedStatuses []apiFeedStatus `json:"feed_statuses"`
+}
+
+func nullString(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func nullTime(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+func respondJSON(w http.ResponseWriter, status int, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("error marshaling json response: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	if status >= http.StatusInternalServerError {
+		log.Printf("api error: %v", message)
+	}
+	respondJSON(w, status, map[string]string{"error": message})
+}
+
+func errorStatus(err error) int {
+	var notFound *notFoundError
+	var ambiguous *ambiguousError
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.As(err, &notFound):
+		return http.StatusNotFound
+	case errors.As(err, &ambiguous):
+		return http.StatusConflict
+	case errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func decodeBody(r *http.Request, payload any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(payload)
+	if err != nil {
+		return fmt.Errorf("invalid request body: %v", err)
+	}
+	return nil
+}
+
+func requestApiKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	scheme, key, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(key)
+	}
+	return ""
+}
+
+func userForApiKey(ctx context.Context, s *state, key string) (database.User, error) {
+	hash := hashApiKey(key)
+	user, err := s.db.GetUserByApiKeyHash(ctx, hash)
+	if err != nil {
+		return database.User{}, err
+	}
+	err = s.db.TouchApiKeyByHash(ctx, database.TouchApiKeyByHashParams{
+		LastUsedAt: sql.NullTime{
+			Time: time.Now().UTC(),
+			Valid: true,
+		},
+		KeyHash: hash,
+	})
+	if err != nil {
+		log.Printf("error recording api key use: %v", err)
+	}
+	return user, nil
+}
+
+func middlewareApiKey(s *state, handler apiHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := requestApiKey(r)
+		if key == "" {
+			respondError(w, http.StatusUnauthorized, "missing api key")
+			return
+		}
+		user, err := userForApiKey(r.Context(), s, key)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusUnauthorized, "invalid api key")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("error retrieving api key from database: %v", err))
+			return
+		}
+		handler(s, w, r, user)
+	}
+}
+
+func apiGetMe(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	respondJSON(w, http.StatusOK, apiUser{
+		ID: user.ID,
+		Name: user.Name,
+		CreatedAt: user.CreatedAt,
+	})
+}
+
+func apiGetUsers(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	users, err := s.db.GetAllUsers(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("error retrieving users from database: %v", err))
+		return
+	}
+	response := make([]apiUser, 0, len(users))
+	for _, u := range users {
+		response = append(response, apiUser{
+			ID: u.ID,
+			Name: u.Name,
+			CreatedAt: u.CreatedAt,
+		})
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+func apiGetFeeds(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	feeds, err := s.db.GetAllFeeds(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("error retrieving feeds from database: %v", err))
+		return
+	}
+	response := make([]apiFeed, 0, len(feeds))
+	for _, feed := range feeds {
+		response = append(response, apiFeed{
+			ID: feed.ID,
+			Name: feed.Name.String,
+			Url: feed.Url,
+			CreatedAt: feed.CreatedAt,
+		})
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+func apiCreateFeed(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	var body struct {
+		Name string `json:"name"`
+		Url string `json:"url"`
+	}
+	err := decodeBody(r, &body)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if body.Name == "" || body.Url == "" {
+		respondError(w, http.StatusBadRequest, "feed name and url must be provided")
+		return
+	}
+	feedURL, err := discoverFeedURL(r.Context(), body.Url, false)
+	if err != nil {
+		respondError(w, http.StatusUnprocessableEntity, fmt.Sprintf("error discovering feed: %v", err))
+		return
+	}
+	feed, err := addFeed(r.Context(), s, user, body.Name, feedURL)
+	if err != nil {
+		respondError(w, errorStatus(err), err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, apiFeed{
+		ID: feed.ID,
+		Name: feed.Name.String,
+		Url: feed.Url,
+		CreatedAt: feed.CreatedAt,
+	})
+}
+
+func apiGetFollows(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	feeds, err := s.db.GetFollowedFeedsByUserID(r.Context(), user.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("error retrieving feed_follows from database: %v", err))
+		return
+	}
+	response := make([]apiFollow, 0, len(feeds))
+	for _, feed := range feeds {
+		response = append(response, apiFollow{
+			Name: feed.Name.String,
+			Url: feed.Url,
+			SiteUrl: nullString(feed.SiteUrl),
+			Folder: nullString(feed.FolderName),
+		})
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+func apiCreateFollow(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	var body struct {
+		Url string `json:"url"`
+	}
+	err := decodeBody(r, &body)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if body.Url == "" {
+		respondError(w, http.StatusBadRequest, "feed url must be provided")
+		return
+	}
+	follow, err := followFeed(r.Context(), s, user, body.Url)
+	if err != nil {
+		respondError(w, errorStatus(err), err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, apiFollow{
+		Name: follow.FeedName.String,
+		Url: body.Url,
+	})
+}
+
+func apiDeleteFollow(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		respondError(w, http.StatusBadRequest, "feed url must be provided")
+		return
+	}
+	err := unfollowFeed(r.Context(), s, user, url)
+	if err != nil {
+		respondError(w, errorStatus(err), err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func apiGetPosts(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	query := r.URL.Query()
+	limit := 20
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit: %v", value))
+			return
+		}
+		limit = n
+	}
+	offset := 0
+	if value := query.Get("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid offset: %v", value))
+			return
+		}
+		offset = n
+	}
+	unread, _ := strconv.ParseBool(query.Get("unread"))
+	edited, _ := strconv.ParseBool(query.Get("updated"))
+	posts, err := browsePosts(r.Context(), s, user, browseOptions{
+		UnreadOnly: unread,
+		EditedOnly: edited,
+		Folder: query.Get("folder"),
+		Query: query.Get("q"),
+		Limit: int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		respondError(w, errorStatus(err), err.Error())
+		return
+	}
+	response := make([]apiPost, 0, len(posts))
+	for _, post := range posts {
+		response = append(response, apiPost{
+			ID: post.ID,
+			FeedID: post.FeedID,
+			Title: post.Title.String,
+			Url: post.Url,
+			Description: post.Description.String,
+			Content: nullString(post.Content),
+			Author: nullString(post.Author),
+			Categories: post.Categories,
+			PublishedAt: nullTime(post.PublishedAt),
+			ReadAt: nullTime(post.ReadAt),
+			StarredAt: nullTime(post.StarredAt),
+			EditedAt: nullTime(post.EditedAt),
+			Muted: post.Muted,
+		})
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+func apiMarkRead(read bool) apiHandler {
+	return func(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+		var body struct {
+			Target string `json:"target"`
+		}
+		err := decodeBody(r, &body)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if body.Target == "" {
+			respondError(w, http.StatusBadRequest, "target must be a post id, post url, feed url or \"all\"")
+			return
+		}
+		target, err := resolveReadTarget(r.Context(), s, user, body.Target)
+		if err != nil {
+			respondError(w, errorStatus(err), err.Error())
+			return
+		}
+		var marked int64
+		if read {
+			marked, err = markTargetRead(r.Context(), s, user, target)
+		} else {
+			marked, err = markTargetUnread(r.Context(), s, user, target)
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]int64{"marked": marked})
+	}
+}
+
+func apiGetStatus(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	feeds, err := s.db.GetFeedStatuses(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("error retrieving feed statuses from database: %v", err))
+		return
+	}
+	status := apiStatus{
+		Feeds: len(feeds),
+		FeedStatuses: make([]apiFeedStatus, 0, len(feeds)),
+	}
+	for _, feed := range feeds {
+		switch {
+		case feed.DisabledAt.Valid:
+			status.Disabled++
+		case feed.ConsecutiveFailures > 0:
+			status.Failing++
+		default:
+			status.Healthy++
+		}
+		if !feed.DisabledAt.Valid && feed.NextFetchAt.Valid && (status.NextFetchAt == nil || feed.NextFetchAt.Time.Before(*status.NextFetchAt)) {
+			status.NextFetchAt = nullTime(feed.NextFetchAt)
+		}
+		var lastStatus *int32
+		if feed.LastStatus.Valid {
+			lastStatus = &feed.LastStatus.Int32
+		}
+		status.FeedStatuses = append(status.FeedStatuses, apiFeedStatus{
+			ID: feed.ID,
+			Name: feed.Name.String,
+			Url: feed.Url,
+			LastFetchedAt: nullTime(feed.LastFetchedAt),
+			NextFetchAt: nullTime(feed.NextFetchAt),
+			LastStatus: lastStatus,
+			LastError: nullString(feed.LastError),
+			ConsecutiveFailures: feed.ConsecutiveFailures,
+			DisabledAt: nullTime(feed.DisabledAt),
+		})
+	}
+	respondJSON(w, http.StatusOK, status)
+}
+
+func apiRoutes(s *state, mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/me", middlewareApiKey(s, apiGetMe))
+	mux.HandleFunc("GET /api/users", middlewareApiKey(s, apiGetUsers))
+	mux.HandleFunc("GET /api/feeds", middlewareApiKey(s, apiGetFeeds))
+	mux.HandleFunc("POST /api/feeds", middlewareApiKey(s, apiCreateFeed))
+	mux.HandleFunc("GET /api/follows", middlewareApiKey(s, apiGetFollows))
+	mux.HandleFunc("POST /api/follows", middlewareApiKey(s, apiCreateFollow))
+	mux.HandleFunc("DELETE /api/follows", middlewareApiKey(s, apiDeleteFollow))
+	mux.HandleFunc("GET /api/posts", middlewareApiKey(s, apiGetPosts))
+	mux.HandleFunc("POST /api/read", middlewareApiKey(s, apiMarkRead(true)))
+	mux.HandleFunc("POST /api/unread", middlewareApiKey(s, apiMarkRead(false)))
+	mux.HandleFunc("GET /api/status", middlewareApiKey(s, apiGetStatus))
+	mux.HandleFunc("GET /api/river", middlewareApiKey(s, apiGetRiver))
+	mux.HandleFunc("GET /river/{token}", riverTokenHandler(s))
+}
+
+func HandlerServe(s *state, cmd command) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "address the http server listens on")
+	_, err := parseArguments(flags, cmd.Arguments)
+	if err != nil {
+		return fmt.Errorf("error parsing serve flags: %v", err)
+	}
+	mux := http.NewServeMux()
+	apiRoutes(s, mux)
+	webRoutes(s, mux)
+	server := &http.Server{
+		Addr: *addr,
+		Handler: mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Printf("serving web reader and api on %v\n", *addr)
+	err = server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error serving http: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Lynn-Xy/bloggatog/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	apiKeyPrefix = "gator_"
+	riverTokenPrefix = "river_"
+)
+
+func generateSecret(prefix string) (string, error) {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", fmt.Errorf("error generating secret: %v", err)
+	}
+	return prefix + hex.EncodeToString(buf), nil
+}
+
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func HandlerApiKey(s *state, cmd command, user database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return errors.New("usage: apikey list | create <name> | revoke <id>")
+	}
+	ctx := context.Background()
+	args := cmd.Arguments[1:]
+	switch cmd.Arguments[0] {
+	case "list":
+		keys, err := s.db.GetApiKeysByUserID(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("error retrieving api keys from database: %v", err)
+		}
+		for _, key := range keys {
+			lastUsed := "never"
+			if key.LastUsedAt.Valid {
+				lastUsed = key.LastUsedAt.Time.Format(time.RFC3339)
+			}
+			fmt.Printf("* %v: %v (created %v, last used %v)\n", key.ID, key.Name, key.CreatedAt.Format(time.RFC3339), lastUsed)
+		}
+	case "create":
+		if len(args) < 1 {
+			return errors.New("api key name must be provided")
+		}
+		key, err := generateSecret(apiKeyPrefix)
+		if err != nil {
+			return err
+		}
+		created, err := s.db.CreateApiKey(ctx, database.CreateApiKeyParams{
+			ID: uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UserID: user.ID,
+			Name: args[0],
+			KeyHash: hashApiKey(key),
+		})
+		if err != nil {
+			return fmt.Errorf("error creating api key in database: %v", err)
+		}
+		fmt.Printf("created api key %v (%v)\n", created.ID, created.Name)
+		fmt.Printf("key: %v\n", key)
+		fmt.Println("store it now, it cannot be shown again")
+	case "revoke":
+		if len(args) < 1 {
+			return errors.New("api key id must be provided")
+		}
+		id, err := uuid.Parse(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid api key id: %v", args[0])
+		}
+		deleted, err := s.db.DeleteApiKeyByID(ctx, database.DeleteApiKeyByIDParams{
+			ID: id,
+			UserID: user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("error revoking api key: %v", err)
+		}
+		if deleted == 0 {
+			return fmt.Errorf("no api key with id: %v", args[0])
+		}
+		fmt.Printf("revoked api key %v\n", id)
+	default:
+		return fmt.Errorf("unknown apikey subcommand: %v", cmd.Arguments[0])
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type atomEntry struct {
+	ID string `xml:"id"`
+	Title atomText `xml:"title"`
+	Links []atomLink `xml:"link"`
+	Updated string `xml:"updated"`
+	Published string `xml:"published"`
+	Summary atomText `xml:"summary"`
+	Content atomText `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name `xml:"feed"`
+	ID string `xml:"id"`
+	Title atomText `xml:"title"`
+	Subtitle atomText `xml:"subtitle"`
+	Links []atomLink `xml:"link"`
+	Updated string `xml:"updated"`
+	Entry []atomEntry `xml:"entry"`
+}
+
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+func alternateLink(links []atomLink) string {
+	var fallback string
+	for _, link := range links {
+		if link.Rel != "" && link.Rel != "alternate" {
+			continue
+		}
+		if link.Type == "" || strings.Contains(link.Type, "html") {
+			return link.Href
+		}
+		if fallback == "" {
+			fallback = link.Href
+		}
+	}
+	if fallback == "" && len(links) > 0 {
+		fallback = links[0].Href
+	}
+	return fallback
+}
+
+func parseAtomFeed(data []byte) (*RSSFeed, error) {
+	var atom atomFeed
+	err := xml.Unmarshal(data, &atom)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing atom feed: %v", err)
+	}
+	var feed RSSFeed
+	feed.Channel.Title = atom.Title.String()
+	feed.Channel.Link = alternateLink(atom.Links)
+	feed.Channel.Description = atom.Subtitle.String()
+	for _, entry := range atom.Entry {
+		item := RSSItem{
+			Title: entry.Title.String(),
+			Link: alternateLink(entry.Links),
+			Description: entry.Summary.String(),
+			PubDate: entry.Published,
+			GUID: entry.ID,
+		}
+		if item.Description == "" {
+			item.Description = entry.Content.String()
+		}
+		if item.PubDate == "" {
+			item.PubDate = entry.Updated
+		}
+		if item.Link == "" && strings.HasPrefix(entry.ID, "http") {
+			item.Link = entry.ID
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "enclosure" && link.Href != "" {
+				item.Enclosures = append(item.Enclosures, RSSEnclosure{
+					URL: link.Href,
+					Length: link.Length,
+					Type: link.Type,
+				})
+			}
+		}
+		for _, category := range entry.Categories {
+			if category.Term != "" {
+				item.Categories = append(item.Categories, category.Term)
+			} else if category.Label != "" {
+				item.Categories = append(item.Categories, category.Label)
+			}
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+	return &feed, nil
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var allowedContentAttrs = map[atom.Atom][]string{
+	atom.A: {"href", "title"},
+	atom.Img: {"src", "alt", "title", "width", "height"},
+	atom.P: nil,
+	atom.Br: nil,
+	atom.Hr: nil,
+	atom.H1: nil,
+	atom.H2: nil,
+	atom.H3: nil,
+	atom.H4: nil,
+	atom.H5: nil,
+	atom.H6: nil,
+	atom.Ul: nil,
+	atom.Ol: {"start"},
+	atom.Li: nil,
+	atom.Dl: nil,
+	atom.Dt: nil,
+	atom.Dd: nil,
+	atom.Blockquote: {"cite"},
+	atom.Pre: nil,
+	atom.Code: nil,
+	atom.Em: nil,
+	atom.Strong: nil,
+	atom.B: nil,
+	atom.I: nil,
+	atom.U: nil,
+	atom.S: nil,
+	atom.Del: nil,
+	atom.Ins: nil,
+	atom.Sub: nil,
+	atom.Sup: nil,
+	atom.Small: nil,
+	atom.Abbr: {"title"},
+	atom.Q: {"cite"},
+	atom.Figure: nil,
+	atom.Figcaption: nil,
+	atom.Table: nil,
+	atom.Thead: nil,
+	atom.Tbody: nil,
+	atom.Tfoot: nil,
+	atom.Tr: nil,
+	atom.Th: {"colspan", "rowspan"},
+	atom.Td: {"colspan", "rowspan"},
+	atom.Div: nil,
+	atom.Span: nil,
+}
+
+var droppedContentTags = map[atom.Atom]bool{
+	atom.Script: true,
+	atom.Style: true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Iframe: true,
+	atom.Frame: true,
+	atom.Frameset: true,
+	atom.Object: true,
+	atom.Embed: true,
+	atom.Applet: true,
+	atom.Svg: true,
+	atom.Math: true,
+	atom.Form: true,
+	atom.Input: true,
+	atom.Button: true,
+	atom.Select: true,
+	atom.Textarea: true,
+	atom.Head: true,
+	atom.Title: true,
+	atom.Meta: true,
+	atom.Link: true,
+	atom.Base: true,
+}
+
+var contentURLSchemes = map[string]bool{
+	"http": true,
+	"https": true,
+	"mailto": true,
+}
+
+func safeContentURL(value string, base *url.URL) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		parsed = base.ResolveReference(parsed)
+	}
+	if !contentURLSchemes[strings.ToLower(parsed.Scheme)] {
+		return "", false
+	}
+	return parsed.String(), true
+}
+
+func isTrackingPixel(node *html.Node) bool {
+	for _, attr := range node.Attr {
+		if attr.Key != "width" && attr.Key != "height" {
+			continue
+		}
+		size, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(attr.Val), "px"))
+		if err == nil && size <= 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func sanitizeNode(node *html.Node, base *url.URL) []*html.Node {
+	switch node.Type {
+	case html.TextNode:
+		return []*html.Node{{Type: html.TextNode, Data: node.Data}}
+	case html.ElementNode:
+	default:
+		return nil
+	}
+	if droppedContentTags[node.DataAtom] {
+		return nil
+	}
+	var children []*html.Node
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		children = append(children, sanitizeNode(child, base)...)
+	}
+	allowed, ok := allowedContentAttrs[node.DataAtom]
+	if !ok {
+		return children
+	}
+	clean := &html.Node{Type: html.ElementNode, DataAtom: node.DataAtom, Data: node.DataAtom.String()}
+	for _, attr := range node.Attr {
+		if attr.Namespace != "" {
+			continue
+		}
+		for _, key := range allowed {
+			if attr.Key != key {
+				continue
+			}
+			value := attr.Val
+			if key == "href" || key == "src" || key == "cite" {
+				safe, ok := safeContentURL(value, base)
+				if !ok {
+					break
+				}
+				value = safe
+			}
+			clean.Attr = append(clean.Attr, html.Attribute{Key: key, Val: value})
+		}
+	}
+	switch node.DataAtom {
+	case atom.Img:
+		if nodeAttr(clean, "src") == "" || isTrackingPixel(clean) {
+			return nil
+		}
+	case atom.A:
+		if nodeAttr(clean, "href") == "" {
+			return children
+		}
+		clean.Attr = append(clean.Attr, html.Attribute{Key: "rel", Val: "noopener noreferrer nofollow"})
+	}
+	for _, child := range children {
+		clean.AppendChild(child)
+	}
+	return []*html.Node{clean}
+}
+
+func parseContent(content string) ([]*html.Node, error) {
+	body := &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"}
+	return html.ParseFragment(strings.NewReader(content), body)
+}
+
+func sanitizeHTML(content string, base *url.URL) string {
+	nodes, err := parseContent(content)
+	if err != nil {
+		return html.EscapeString(content)
+	}
+	var b bytes.Buffer
+	for _, node := range nodes {
+		for _, clean := range sanitizeNode(node, base) {
+			err := html.Render(&b, clean)
+			if err != nil {
+				return html.EscapeString(content)
+			}
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+type textRenderer struct {
+	width int
+	lines []string
+	inline strings.Builder
+	prefix string
+	first string
+	needBlank bool
+	listDepth int
+	links []string
+}
+
+func (r *textRenderer) emit(line string) {
+	if r.needBlank && len(r.lines) > 0 && strings.Trim(r.lines[len(r.lines)-1], "> ") != "" {
+		blank := ""
+		if strings.HasPrefix(r.lines[len(r.lines)-1], r.prefix) {
+			blank = strings.TrimRight(r.prefix, " ")
+		}
+		r.lines = append(r.lines, blank)
+	}
+	r.needBlank = false
+	r.lines = append(r.lines, line)
+}
+
+func (r *textRenderer) flush() {
+	text := strings.Join(strings.Fields(r.inline.String()), " ")
+	r.inline.Reset()
+	if text == "" {
+		return
+	}
+	first := r.prefix
+	if r.first != "" {
+		first = r.first
+		r.first = ""
+	}
+	width := r.width - len([]rune(r.prefix))
+	if width < 20 {
+		width = 20
+	}
+	for idx, line := range wrapText(text, width) {
+		if idx == 0 {
+			r.emit(first + line)
+		} else {
+			r.emit(r.prefix + line)
+		}
+	}
+}
+
+func (r *textRenderer) block() {
+	r.flush()
+	r.needBlank = true
+}
+
+func (r *textRenderer) footnote(link string) int {
+	for idx, existing := range r.links {
+		if existing == link {
+			return idx + 1
+		}
+	}
+	r.links = append(r.links, link)
+	return len(r.links)
+}
+
+func (r *textRenderer) children(node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		r.render(child)
+	}
+}
+
+func (r *textRenderer) wrapped(node *html.Node, marker string) {
+	r.inline.WriteString(marker)
+	r.children(node)
+	r.inline.WriteString(marker)
+}
+
+func (r *textRenderer) list(node *html.Node) {
+	r.flush()
+	if r.listDepth == 0 {
+		r.needBlank = true
+	}
+	r.listDepth++
+	number := 1
+	if start, err := strconv.Atoi(nodeAttr(node, "start")); err == nil {
+		number = start
+	}
+	prefix := r.prefix
+	for item := node.FirstChild; item != nil; item = item.NextSibling {
+		if item.Type != html.ElementNode || item.DataAtom != atom.Li {
+			r.render(item)
+			continue
+		}
+		r.flush()
+		marker := "* "
+		if node.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%v. ", number)
+			number++
+		}
+		r.first = prefix + marker
+		r.prefix = prefix + strings.Repeat(" ", len(marker))
+		r.children(item)
+		r.flush()
+		r.first = ""
+		r.prefix = prefix
+	}
+	r.listDepth--
+	if r.listDepth == 0 {
+		r.needBlank = true
+	}
+}
+
+func (r *textRenderer) render(node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		r.inline.WriteString(node.Data)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	switch node.DataAtom {
+	case atom.Br:
+		r.flush()
+	case atom.Hr:
+		r.block()
+		r.emit(r.prefix + "---")
+		r.needBlank = true
+	case atom.A:
+		r.children(node)
+		if href := nodeAttr(node, "href"); href != "" && !strings.HasPrefix(href, "#") {
+			fmt.Fprintf(&r.inline, "[%v]", r.footnote(href))
+		}
+	case atom.Img:
+		alt := strings.TrimSpace(nodeAttr(node, "alt"))
+		if alt == "" {
+			alt = "image"
+		} else {
+			alt = "image: " + alt
+		}
+		fmt.Fprintf(&r.inline, " [%v][%v] ", alt, r.footnote(nodeAttr(node, "src")))
+	case atom.Strong, atom.B:
+		r.wrapped(node, "**")
+	case atom.Em, atom.I:
+		r.wrapped(node, "_")
+	case atom.Code:
+		r.wrapped(node, "`")
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.block()
+		level, _ := strconv.Atoi(node.Data[1:])
+		r.inline.WriteString(strings.Repeat("#", level) + " ")
+		r.children(node)
+		r.block()
+	case atom.Ul, atom.Ol:
+		r.list(node)
+	case atom.Blockquote:
+		r.block()
+		prefix := r.prefix
+		r.prefix += "> "
+		r.children(node)
+		r.flush()
+		r.prefix = prefix
+		r.needBlank = true
+	case atom.Pre:
+		r.block()
+		var b strings.Builder
+		var walk func(*html.Node)
+		walk = func(n *html.Node) {
+			if n.Type == html.TextNode {
+				b.WriteString(n.Data)
+			}
+			for child := n.FirstChild; child != nil; child = child.NextSibling {
+				walk(child)
+			}
+		}
+		walk(node)
+		for _, line := range strings.Split(strings.Trim(b.String(), "\n"), "\n") {
+			r.emit(r.prefix + "    " + strings.TrimRight(line, " \t\r"))
+		}
+		r.needBlank = true
+	case atom.Td, atom.Th:
+		r.children(node)
+		r.inline.WriteString("  ")
+	case atom.Tr, atom.Dt, atom.Dd, atom.Li:
+		r.flush()
+		r.children(node)
+		r.flush()
+	case atom.P, atom.Div, atom.Figure, atom.Figcaption, atom.Table, atom.Dl:
+		r.block()
+		r.children(node)
+		r.block()
+	default:
+		r.children(node)
+	}
+}
+
+func renderText(content string, width int) []string {
+	nodes, err := parseContent(sanitizeHTML(content, nil))
+	if err != nil {
+		return wrapText(content, width)
+	}
+	r := &textRenderer{width: width}
+	for _, node := range nodes {
+		r.render(node)
+	}
+	r.flush()
+	if len(r.links) > 0 {
+		r.needBlank = true
+		for idx, link := range r.links {
+			r.emit(fmt.Sprintf("[%v]: %v", idx+1, link))
+		}
+	}
+	return r.lines
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	base, err := url.Parse("https://example.com/posts/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		input string
+		want string
+	}{
+		{
+			name: "keeps allowed markup",
+			input: `<p>Hello <strong>world</strong></p>`,
+			want: `<p>Hello <strong>world</strong></p>`,
+		},
+		{
+			name: "drops javascript href",
+			input: `<a href="javascript:alert(1)">click</a>`,
+			want: `click`,
+		},
+		{
+			name: "drops mixed case javascript href",
+			input: `<a href=" JaVaScRiPt:alert(1)">click</a>`,
+			want: `click`,
+		},
+		{
+			name: "drops data image src",
+			input: `<img src="data:image/svg+xml;base64,PHN2Zz4=" alt="x">`,
+			want: ``,
+		},
+		{
+			name: "drops data href",
+			input: `<a href="data:text/html,<script>alert(1)</script>">x</a>`,
+			want: `x`,
+		},
+		{
+			name: "drops event handler attributes",
+			input: `<p onclick="alert(1)" onmouseover="alert(2)">text</p>`,
+			want: `<p>text</p>`,
+		},
+		{
+			name: "drops event handler on image",
+			input: `<img src="/a.png" onerror="alert(1)">`,
+			want: `<img src="https://example.com/a.png"/>`,
+		},
+		{
+			name: "drops style attribute",
+			input: `<span style="background:url(javascript:alert(1))">text</span>`,
+			want: `<span>text</span>`,
+		},
+		{
+			name: "drops script with content",
+			input: `before<script>alert(1)</script>after`,
+			want: `beforeafter`,
+		},
+		{
+			name: "drops style with content",
+			input: `<style>body{display:none}</style><p>text</p>`,
+			want: `<p>text</p>`,
+		},
+		{
+			name: "drops iframe",
+			input: `<iframe src="https://evil.example/"></iframe><p>text</p>`,
+			want: `<p>text</p>`,
+		},
+		{
+			name: "drops svg with script",
+			input: `<svg><script>alert(1)</script></svg>text`,
+			want: `text`,
+		},
+		{
+			name: "drops one pixel tracking image",
+			input: `<p>text<img src="https://tracker.example/p.gif" width="1" height="1"></p>`,
+			want: `<p>text</p>`,
+		},
+		{
+			name: "drops zero pixel tracking image with px unit",
+			input: `<img src="https://tracker.example/p.gif" width="0px">`,
+			want: ``,
+		},
+		{
+			name: "keeps normal image",
+			input: `<img src="https://example.com/a.png" width="640" alt="a">`,
+			want: `<img src="https://example.com/a.png" width="640" alt="a"/>`,
+		},
+		{
+			name: "resolves relative links and adds rel",
+			input: `<a href="../other">other</a>`,
+			want: `<a href="https://example.com/other" rel="noopener noreferrer nofollow">other</a>`,
+		},
+		{
+			name: "unwraps unknown elements",
+			input: `<section><custom-tag>text</custom-tag></section>`,
+			want: `text`,
+		},
+		{
+			name: "escapes text",
+			input: `a &lt;b&gt; c`,
+			want: `a &lt;b&gt; c`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := sanitizeHTML(tc.input, base)
+			if got != tc.want {
+				t.Errorf("sanitizeHTML(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
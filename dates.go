@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05-07",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -07:00",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 Jan 2006 15:04:05.999999999 -0700",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 Jan 2006",
+	"Jan 2 2006 15:04:05 -0700",
+	"Jan 2 2006 15:04 -0700",
+	"Jan 2 2006 15:04:05",
+	"Jan 2 15:04:05 -0700 2006",
+	"Jan 2 15:04:05 2006",
+	"Jan 2 2006",
+	"2006 Jan 2 15:04:05 -0700",
+}
+
+var zoneOffsets = map[string]string{
+	"UT": "+0000",
+	"UTC": "+0000",
+	"GMT": "+0000",
+	"Z": "+0000",
+	"WET": "+0000",
+	"WEST": "+0100",
+	"BST": "+0100",
+	"CET": "+0100",
+	"CEST": "+0200",
+	"MET": "+0100",
+	"MEST": "+0200",
+	"EET": "+0200",
+	"EEST": "+0300",
+	"MSK": "+0300",
+	"IST": "+0530",
+	"SGT": "+0800",
+	"HKT": "+0800",
+	"JST": "+0900",
+	"KST": "+0900",
+	"AWST": "+0800",
+	"ACST": "+0930",
+	"ACDT": "+1030",
+	"AEST": "+1000",
+	"AEDT": "+1100",
+	"NZST": "+1200",
+	"NZDT": "+1300",
+	"AST": "-0400",
+	"ADT": "-0300",
+	"EST": "-0500",
+	"EDT": "-0400",
+	"CST": "-0600",
+	"CDT": "-0500",
+	"MST": "-0700",
+	"MDT": "-0600",
+	"PST": "-0800",
+	"PDT": "-0700",
+	"AKST": "-0900",
+	"AKDT": "-0800",
+	"HST": "-1000",
+}
+
+var monthNames = map[string]string{
+	"january": "Jan",
+	"february": "Feb",
+	"march": "Mar",
+	"april": "Apr",
+	"june": "Jun",
+	"july": "Jul",
+	"august": "Aug",
+	"sept": "Sep",
+	"september": "Sep",
+	"october": "Oct",
+	"november": "Nov",
+	"december": "Dec",
+}
+
+var weekdayNames = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+var (
+	dateCommentPattern = regexp.MustCompile(`\s*\([^)]*\)\s*$`)
+	gmtOffsetPattern = regexp.MustCompile(`(?i)\b(?:GMT|UTC)([+-])(\d{1,2}):?(\d{2})?$`)
+	shortOffsetPattern = regexp.MustCompile(`([+-])(\d)(\d{2})$`)
+)
+
+func normalizeDate(raw string) string {
+	value := strings.TrimSpace(raw)
+	value = dateCommentPattern.ReplaceAllString(value, "")
+	value = strings.ReplaceAll(value, ",", " ")
+	if match := gmtOffsetPattern.FindStringSubmatch(value); match != nil {
+		minutes := match[3]
+		if minutes == "" {
+			minutes = "00"
+		}
+		value = fmt.Sprintf("%v %v%02v%v", strings.TrimSpace(value[:len(value)-len(match[0])]), match[1], match[2], minutes)
+	}
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return ""
+	}
+	first := strings.ToLower(strings.TrimSuffix(fields[0], "."))
+	for _, weekday := range weekdayNames {
+		if len(fields) > 1 && strings.HasPrefix(first, weekday) {
+			fields = fields[1:]
+			break
+		}
+	}
+	for idx, field := range fields {
+		if month, ok := monthNames[strings.ToLower(strings.TrimSuffix(field, "."))]; ok {
+			fields[idx] = month
+		} else if len(field) == 4 && strings.HasSuffix(field, ".") {
+			fields[idx] = strings.TrimSuffix(field, ".")
+		}
+	}
+	last := len(fields) - 1
+	if offset, ok := zoneOffsets[strings.ToUpper(fields[last])]; ok && last > 0 {
+		fields[last] = offset
+	}
+	value = strings.Join(fields, " ")
+	value = shortOffsetPattern.ReplaceAllString(value, "${1}0${2}${3}")
+	return value
+}
+
+func parseDate(raw string) (time.Time, error) {
+	value := normalizeDate(raw)
+	if value == "" {
+		return time.Time{}, errors.New("empty publication date")
+	}
+	for _, layout := range dateLayouts {
+		date, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		if date.Year() < 1971 {
+			return time.Time{}, fmt.Errorf("publication date out of range: %v", raw)
+		}
+		return date.UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized publication date format: %v", raw)
+}
+
+func clampDate(date time.Time, now time.Time) time.Time {
+	if date.After(now) {
+		return now
+	}
+	return date
+}
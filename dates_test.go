@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	cases := []struct {
+		input string
+		want time.Time
+	}{
+		{"Mon, 02 Jan 2006 15:04:05 -0700", time.Date(2006, 1, 2, 22, 4, 5, 0, time.UTC)},
+		{"Mon, 02 Jan 2006 15:04:05 GMT", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"Mon, 2 Jan 2006 15:04:05 EST", time.Date(2006, 1, 2, 20, 4, 5, 0, time.UTC)},
+		{"Mon, 02 Jan 06 15:04 +0000", time.Date(2006, 1, 2, 15, 4, 0, 0, time.UTC)},
+		{"Monday, 02 January 2006 15:04:05 +0100", time.Date(2006, 1, 2, 14, 4, 5, 0, time.UTC)},
+		{"Tue, 3 Sept 2019 08:00:00 +0000", time.Date(2019, 9, 3, 8, 0, 0, 0, time.UTC)},
+		{"Wed, 02 Jan 2006 15:04:05 GMT+2", time.Date(2006, 1, 2, 13, 4, 5, 0, time.UTC)},
+		{"Wed, 02 Jan 2006 15:04:05 +0000 (UTC)", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"2006-01-02T15:04:05Z", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"2006-01-02T15:04:05.123+02:00", time.Date(2006, 1, 2, 13, 4, 5, 123000000, time.UTC)},
+		{"2006-01-02T15:04:05+0200", time.Date(2006, 1, 2, 13, 4, 5, 0, time.UTC)},
+		{"2006-01-02 15:04:05", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"2006-01-02", time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"  2 Jan 2006  ", time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		got, err := parseDate(tc.input)
+		if err != nil {
+			t.Errorf("parseDate(%q) returned error: %v", tc.input, err)
+			continue
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("parseDate(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestParseDateRejects(t *testing.T) {
+	for _, input := range []string{"", "   ", "yesterday", "0001-01-01T00:00:00Z", "1970-01-01"} {
+		_, err := parseDate(input)
+		if err == nil {
+			t.Errorf("parseDate(%q) returned no error", input)
+		}
+	}
+}
+
+func TestClampDate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	future := now.Add(48 * time.Hour)
+	if got := clampDate(future, now); !got.Equal(now) {
+		t.Errorf("clampDate(future) = %v, want %v", got, now)
+	}
+	past := now.Add(-time.Hour)
+	if got := clampDate(past, now); !got.Equal(past) {
+		t.Errorf("clampDate(past) = %v, want %v", got, past)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/Lynn-Xy/bloggatog/internal/database"
+)
+
+const diffContext = 2
+
+type postVersion struct {
+	Label string
+	Title string
+	Url string
+	Body []string
+	Content []string
+}
+
+func diffLines(before []string, after []string) []string {
+	lengths := make([][]int, len(before)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	var lines []string
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			lines = append(lines, "  "+before[i])
+			i++
+			j++
+		case i < len(before) && (j == len(after) || lengths[i+1][j] >= lengths[i][j+1]):
+			lines = append(lines, "- "+before[i])
+			i++
+		default:
+			lines = append(lines, "+ "+after[j])
+			j++
+		}
+	}
+	return lines
+}
+
+func trimDiffContext(lines []string) []string {
+	keep := make([]bool, len(lines))
+	for idx, line := range lines {
+		if line[0] == ' ' {
+			continue
+		}
+		for k := max(0, idx-diffContext); k <= min(len(lines)-1, idx+diffContext); k++ {
+			keep[k] = true
+		}
+	}
+	var trimmed []string
+	skipped := false
+	for idx, line := range lines {
+		if !keep[idx] {
+			skipped = true
+			continue
+		}
+		if skipped && len(trimmed) > 0 {
+			trimmed = append(trimmed, "...")
+		}
+		skipped = false
+		trimmed = append(trimmed, line)
+	}
+	return trimmed
+}
+
+func printVersionDiff(before postVersion, after postVersion) {
+	fmt.Printf("--- %v\n", before.Label)
+	fmt.Printf("+++ %v\n", after.Label)
+	if before.Title != after.Title {
+		fmt.Printf("title:\n- %v\n+ %v\n", before.Title, after.Title)
+	}
+	if before.Url != after.Url {
+		fmt.Printf("url:\n- %v\n+ %v\n", before.Url, after.Url)
+	}
+	body := trimDiffContext(diffLines(before.Body, after.Body))
+	if len(body) > 0 {
+		fmt.Println("body:")
+		for _, line := range body {
+			fmt.Println(line)
+		}
+	}
+	var content []string
+	if len(before.Content) > 0 && len(after.Content) > 0 {
+		content = trimDiffContext(diffLines(before.Content, after.Content))
+	}
+	if len(content) > 0 {
+		fmt.Println("full content:")
+		for _, line := range content {
+			fmt.Println(line)
+		}
+	}
+	if before.Title == after.Title && before.Url == after.Url && len(body) == 0 && len(content) == 0 {
+		fmt.Println("no visible changes")
+	}
+}
+
+func HandlerDiff(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	all := flags.Bool("all", false, "show every revision instead of only the latest change")
+	args, err := parseArguments(flags, cmd.Arguments)
+	if err != nil {
+		return fmt.Errorf("error parsing diff flags: %v", err)
+	}
+	if len(args) < 1 {
+		return errors.New("post id or post url must be provided")
+	}
+	ctx := context.Background()
+	post, err := findPost(ctx, s, user, args[0])
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no post found matching: %v", args[0])
+	}
+	if err != nil {
+		return err
+	}
+	revisions, err := s.db.GetPostRevisionsByPostID(ctx, post.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving post revisions from database: %v", err)
+	}
+	if len(revisions) == 0 {
+		fmt.Printf("%v has not been edited since it was first fetched\n", post.Title.String)
+		return nil
+	}
+	var versions []postVersion
+	for idx, revision := range revisions {
+		versions = append(versions, postVersion{
+			Label: fmt.Sprintf("revision %v (replaced %v)", idx+1, revision.CreatedAt.Format("2006-01-02 15:04")),
+			Title: revision.Title.String,
+			Url: revision.Url,
+			Body: renderText(revision.Description.String, 76),
+			Content: renderText(revision.Content.String, 76),
+		})
+	}
+	edited := post.UpdatedAt
+	if post.EditedAt.Valid {
+		edited = post.EditedAt.Time
+	}
+	versions = append(versions, postVersion{
+		Label: fmt.Sprintf("current (updated %v)", edited.Format("2006-01-02 15:04")),
+		Title: post.Title.String,
+		Url: post.Url,
+		Body: renderText(post.Description.String, 76),
+		Content: renderText(post.Content.String, 76),
+	})
+	start := len(versions) - 2
+	if *all {
+		start = 0
+	}
+	fmt.Printf("%v: %v earlier versions\n", post.Title.String, len(revisions))
+	for idx := start; idx < len(versions)-1; idx++ {
+		fmt.Println()
+		printVersionDiff(versions[idx], versions[idx+1])
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	cases := []struct {
+		name string
+		before []string
+		after []string
+		want []string
+	}{
+		{
+			name: "identical",
+			before: []string{"a", "b"},
+			after: []string{"a", "b"},
+			want: []string{"  a", "  b"},
+		},
+		{
+			name: "changed line",
+			before: []string{"a", "b", "c"},
+			after: []string{"a", "x", "c"},
+			want: []string{"  a", "- b", "+ x", "  c"},
+		},
+		{
+			name: "added lines",
+			before: []string{"a"},
+			after: []string{"a", "b", "c"},
+			want: []string{"  a", "+ b", "+ c"},
+		},
+		{
+			name: "removed lines",
+			before: []string{"a", "b", "c"},
+			after: []string{"c"},
+			want: []string{"- a", "- b", "  c"},
+		},
+		{
+			name: "empty before",
+			before: nil,
+			after: []string{"a"},
+			want: []string{"+ a"},
+		},
+		{
+			name: "both empty",
+			before: nil,
+			after: nil,
+			want: nil,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := diffLines(tc.before, tc.after)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("diffLines(%q, %q) = %q, want %q", tc.before, tc.after, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTrimDiffContext(t *testing.T) {
+	cases := []struct {
+		name string
+		lines []string
+		want []string
+	}{
+		{
+			name: "no changes",
+			lines: []string{"  a", "  b", "  c"},
+			want: nil,
+		},
+		{
+			name: "keeps context around a change",
+			lines: []string{"  1", "  2", "  3", "  4", "- 5", "+ 6", "  7", "  8", "  9"},
+			want: []string{"  3", "  4", "- 5", "+ 6", "  7", "  8"},
+		},
+		{
+			name: "separates distant changes",
+			lines: []string{"- a", "  1", "  2", "  3", "  4", "  5", "+ b"},
+			want: []string{"- a", "  1", "  2", "...", "  4", "  5", "+ b"},
+		},
+		{
+			name: "merges close changes",
+			lines: []string{"- a", "  1", "  2", "  3", "+ b"},
+			want: []string{"- a", "  1", "  2", "  3", "+ b"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := trimDiffContext(tc.lines)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("trimDiffContext(%q) = %q, want %q", tc.lines, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	discoveryTimeout = 15 * time.Second
+	discoveryMaxBytes = 5 << 20
+)
+
+var commonFeedPaths = []string{
+	"/feed",
+	"/rss",
+	"/feed.xml",
+	"/rss.xml",
+	"/atom.xml",
+	"/index.xml",
+	"/feed.json",
+}
+
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml": true,
+	"application/atom+xml": true,
+	"application/rdf+xml": true,
+	"application/feed+json": true,
+	"application/json": true,
+}
+
+var (
+	htmlIgnoredPattern = regexp.MustCompile(`(?is)<!--.*?-->|<script\b.*?</script\s*>|<style\b.*?</style\s*>`)
+	htmlLinkPattern = regexp.MustCompile(`(?is)<(link|base)\b([^>]*)>`)
+	htmlAttrPattern = regexp.MustCompile(`(?is)([a-z][a-z0-9:_-]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+type feedCandidate struct {
+	URL string
+	Title string
+	Format string
+	Items int
+}
+
+func fetchDocument(ctx context.Context, documentURL string) ([]byte, string, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", documentURL, nil)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("error creating http request: %v", err)
+	}
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/feed+json, text/html;q=0.9, */*;q=0.8")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("error sending http get request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", nil, fmt.Errorf("error retrieving %v: server response %v", documentURL, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, discoveryMaxBytes))
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("error reading http get response body: %v", err)
+	}
+	return data, resp.Header.Get("Content-Type"), resp.Request.URL, nil
+}
+
+func feedFormat(data []byte, contentType string) string {
+	if isJSONFeed(contentType, data) {
+		return "JSON Feed"
+	}
+	root, err := feedRootElement(data)
+	if err != nil {
+		return ""
+	}
+	switch root.Local {
+	case "rss":
+		return "RSS"
+	case "feed":
+		return "Atom"
+	case "RDF":
+		return "RDF"
+	}
+	return ""
+}
+
+func parseCandidate(data []byte, contentType string, feedURL string) (feedCandidate, error) {
+	feed, err := parseFeed(data, contentType)
+	if err != nil {
+		return feedCandidate{}, err
+	}
+	return feedCandidate{
+		URL: feedURL,
+		Title: strings.TrimSpace(feed.Channel.Title),
+		Format: feedFormat(data, contentType),
+		Items: len(feed.Channel.Item),
+	}, nil
+}
+
+func feedLinksFromHTML(data []byte, base *url.URL) []string {
+	document := htmlIgnoredPattern.ReplaceAllString(string(data), "")
+	var links []string
+	for _, tag := range htmlLinkPattern.FindAllStringSubmatch(document, -1) {
+		attrs := make(map[string]string)
+		for _, attr := range htmlAttrPattern.FindAllStringSubmatch(tag[2], -1) {
+			attrs[strings.ToLower(attr[1])] = strings.TrimSpace(html.UnescapeString(attr[2] + attr[3] + attr[4]))
+		}
+		href, err := base.Parse(attrs["href"])
+		if attrs["href"] == "" || err != nil {
+			continue
+		}
+		if strings.EqualFold(tag[1], "base") {
+			base = href
+			continue
+		}
+		rels := strings.Fields(strings.ToLower(attrs["rel"]))
+		mediaType, _, _ := strings.Cut(strings.ToLower(attrs["type"]), ";")
+		alternate := false
+		for _, rel := range rels {
+			if rel == "alternate" || rel == "feed" {
+				alternate = true
+			}
+		}
+		if alternate && feedLinkTypes[strings.TrimSpace(mediaType)] {
+			links = append(links, href.String())
+		}
+	}
+	return links
+}
+
+func probeURLs(page *url.URL) []string {
+	pagePath := "/" + strings.TrimPrefix(page.Path, "/")
+	dirs := []string{path.Dir(pagePath), ""}
+	if strings.HasSuffix(pagePath, "/") || path.Ext(pagePath) == "" {
+		dirs = append([]string{pagePath}, dirs...)
+	}
+	var urls []string
+	for _, dir := range dirs {
+		for _, feedPath := range commonFeedPaths {
+			probe := url.URL{Scheme: page.Scheme, Host: page.Host, Path: strings.TrimSuffix(dir, "/") + feedPath}
+			urls = append(urls, probe.String())
+		}
+	}
+	return urls
+}
+
+func discoverFeeds(ctx context.Context, pageURL string) ([]feedCandidate, error) {
+	ctx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+	data, contentType, finalURL, err := fetchDocument(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if candidate, err := parseCandidate(data, contentType, pageURL); err == nil {
+		return []feedCandidate{candidate}, nil
+	}
+	urls := feedLinksFromHTML(data, finalURL)
+	if len(urls) == 0 {
+		urls = probeURLs(finalURL)
+	}
+	seen := make(map[string]bool)
+	var unique []string
+	for _, candidateURL := range urls {
+		if !seen[candidateURL] {
+			seen[candidateURL] = true
+			unique = append(unique, candidateURL)
+		}
+	}
+	results := make([]*feedCandidate, len(unique))
+	finalURLs := make([]string, len(unique))
+	var wg sync.WaitGroup
+	for idx, candidateURL := range unique {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, contentType, fetchedURL, err := fetchDocument(ctx, candidateURL)
+			if err != nil {
+				return
+			}
+			candidate, err := parseCandidate(data, contentType, candidateURL)
+			if err == nil {
+				results[idx] = &candidate
+				finalURLs[idx] = fetchedURL.String()
+			}
+		}()
+	}
+	wg.Wait()
+	found := make(map[string]bool)
+	var candidates []feedCandidate
+	for idx, result := range results {
+		if result == nil || found[finalURLs[idx]] {
+			continue
+		}
+		found[finalURLs[idx]] = true
+		candidates = append(candidates, *result)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return !isCommentFeed(candidates[i]) && isCommentFeed(candidates[j])
+	})
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no rss, atom or json feeds found at %v", pageURL)
+	}
+	return candidates, nil
+}
+
+func isCommentFeed(candidate feedCandidate) bool {
+	text := strings.ToLower(candidate.Title + " " + candidate.URL)
+	return strings.Contains(text, "comment")
+}
+
+func promptFeedCandidate(candidates []feedCandidate) (feedCandidate, error) {
+	fmt.Println("found multiple feeds:")
+	for idx, candidate := range candidates {
+		title := candidate.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		fmt.Printf("  %v) %v [%v, %v items]\n     %v\n", idx+1, title, candidate.Format, candidate.Items, candidate.URL)
+	}
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("choose a feed [1-%v, default 1]: ", len(candidates))
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return feedCandidate{}, fmt.Errorf("error reading choice: %v", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return candidates[0], nil
+		}
+		choice, convErr := strconv.Atoi(line)
+		if convErr == nil && choice >= 1 && choice <= len(candidates) {
+			return candidates[choice-1], nil
+		}
+		if errors.Is(err, io.EOF) {
+			return feedCandidate{}, fmt.Errorf("invalid choice: %v", line)
+		}
+		fmt.Printf("invalid choice: %v\n", line)
+	}
+}
+
+func discoverFeedURL(ctx context.Context, pageURL string, interactive bool) (string, error) {
+	candidates, err := discoverFeeds(ctx, pageURL)
+	if err != nil {
+		return "", err
+	}
+	if len(candidates) == 1 || !interactive || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return candidates[0].URL, nil
+	}
+	candidate, err := promptFeedCandidate(candidates)
+	if err != nil {
+		return "", err
+	}
+	return candidate.URL, nil
+}
+
+func findDiscoveredFeed(ctx context.Context, s *state, pageURL string) (string, error) {
+	candidates, err := discoverFeeds(ctx, pageURL)
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range candidates {
+		_, err := s.db.GetFeedByUrl(ctx, candidate.URL)
+		if err == nil {
+			return candidate.URL, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("error retrieving feed from database: %v", err)
+		}
+	}
+	return "", fmt.Errorf("no feed from %v has been added yet, use addfeed with %v", pageURL, candidates[0].URL)
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Lynn-Xy/bloggatog/internal/database"
+	"github.com/google/uuid"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	articleTimeout = 20 * time.Second
+	articleMinLength = 200
+	articleMinParagraph = 25
+)
+
+var (
+	unlikelyCandidatePattern = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|footer|header|menu|modal|nav|newsletter|pagination|popup|promo|related|remark|replies|share|shoutbox|sidebar|social|sponsor|subscribe|tags|toolbar|widget|ad-`)
+	likelyCandidatePattern = regexp.MustCompile(`(?i)and|article|body|column|content|entry|main|post|shadow|story|text`)
+	positiveClassPattern = regexp.MustCompile(`(?i)article|blog|body|content|entry|h-entry|main|page|post|story|text`)
+	negativeClassPattern = regexp.MustCompile(`(?i)byline|comment|contact|footer|hidden|masthead|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|social|sponsor|shopping|tags|widget`)
+)
+
+var removedArticleTags = map[atom.Atom]bool{
+	atom.Script: true,
+	atom.Style: true,
+	atom.Noscript: true,
+	atom.Iframe: true,
+	atom.Object: true,
+	atom.Embed: true,
+	atom.Form: true,
+	atom.Button: true,
+	atom.Input: true,
+	atom.Select: true,
+	atom.Textarea: true,
+	atom.Nav: true,
+	atom.Aside: true,
+	atom.Footer: true,
+	atom.Header: true,
+	atom.Svg: true,
+	atom.Link: true,
+	atom.Meta: true,
+}
+
+var scoredArticleTags = map[atom.Atom]bool{
+	atom.P: true,
+	atom.Pre: true,
+	atom.Td: true,
+	atom.Blockquote: true,
+	atom.Li: true,
+	atom.H2: true,
+	atom.H3: true,
+}
+
+func nodeAttr(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func nodeText(node *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteString(" ")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func linkDensity(node *html.Node) float64 {
+	length := len(nodeText(node))
+	if length == 0 {
+		return 0
+	}
+	linkLength := 0
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			linkLength += len(nodeText(n))
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return float64(linkLength) / float64(length)
+}
+
+func classWeight(node *html.Node) float64 {
+	weight := 0.0
+	for _, value := range []string{nodeAttr(node, "class"), nodeAttr(node, "id")} {
+		if value == "" {
+			continue
+		}
+		if negativeClassPattern.MatchString(value) {
+			weight -= 25
+		}
+		if positiveClassPattern.MatchString(value) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+func tagWeight(node *html.Node) float64 {
+	switch node.DataAtom {
+	case atom.Article, atom.Main:
+		return 10
+	case atom.Div:
+		return 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		return 3
+	case atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		return -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		return -5
+	}
+	return 0
+}
+
+func pruneArticleNodes(node *html.Node) {
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+		switch {
+		case child.Type == html.CommentNode:
+			node.RemoveChild(child)
+		case child.Type != html.ElementNode:
+		case removedArticleTags[child.DataAtom]:
+			node.RemoveChild(child)
+		default:
+			match := nodeAttr(child, "class") + " " + nodeAttr(child, "id")
+			if child.DataAtom != atom.Body && child.DataAtom != atom.Article &&
+				unlikelyCandidatePattern.MatchString(match) && !likelyCandidatePattern.MatchString(match) {
+				node.RemoveChild(child)
+			} else {
+				pruneArticleNodes(child)
+			}
+		}
+		child = next
+	}
+}
+
+func findArticleNode(root *html.Node) *html.Node {
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	addScore := func(node *html.Node, score float64) {
+		if node == nil || node.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[node]; !ok {
+			scores[node] = tagWeight(node) + classWeight(node)
+			candidates = append(candidates, node)
+		}
+		scores[node] += score
+	}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && scoredArticleTags[n.DataAtom] {
+			text := nodeText(n)
+			if len(text) >= articleMinParagraph {
+				score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+				addScore(n.Parent, score)
+				if n.Parent != nil {
+					addScore(n.Parent.Parent, score/2)
+				}
+			}
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	var top *html.Node
+	topScore := 0.0
+	for _, node := range candidates {
+		score := scores[node] * (1 - linkDensity(node))
+		if top == nil || score > topScore {
+			top = node
+			topScore = score
+		}
+	}
+	return top
+}
+
+func resolveArticleURLs(node *html.Node, base *url.URL) {
+	if node.Type == html.ElementNode {
+		for idx, attr := range node.Attr {
+			if attr.Key != "href" && attr.Key != "src" {
+				continue
+			}
+			if resolved, err := base.Parse(strings.TrimSpace(attr.Val)); err == nil {
+				node.Attr[idx].Val = resolved.String()
+			}
+		}
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		resolveArticleURLs(child, base)
+	}
+}
+
+func extractArticle(data []byte, base *url.URL) (string, error) {
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("error parsing article html: %v", err)
+	}
+	pruneArticleNodes(root)
+	article := findArticleNode(root)
+	if article == nil {
+		return "", errors.New("no article content found")
+	}
+	if len(nodeText(article)) < articleMinLength {
+		return "", fmt.Errorf("article content too short (%v characters)", len(nodeText(article)))
+	}
+	resolveArticleURLs(article, base)
+	var b bytes.Buffer
+	for child := article.FirstChild; child != nil; child = child.NextSibling {
+		err := html.Render(&b, child)
+		if err != nil {
+			return "", fmt.Errorf("error rendering article html: %v", err)
+		}
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+func fetchArticle(ctx context.Context, articleURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, articleTimeout)
+	defer cancel()
+	data, contentType, finalURL, err := fetchDocument(ctx, articleURL)
+	if err != nil {
+		return "", err
+	}
+	if contentType != "" && !strings.Contains(strings.ToLower(contentType), "html") {
+		return "", fmt.Errorf("unsupported article content type: %v", contentType)
+	}
+	content, err := extractArticle(data, finalURL)
+	if err != nil {
+		return "", err
+	}
+	return sanitizeHTML(content, finalURL), nil
+}
+
+func storePostContent(ctx context.Context, s *state, postID uuid.UUID, postURL string) error {
+	content, err := fetchArticle(ctx, postURL)
+	if err != nil {
+		return err
+	}
+	params := database.UpdatePostContentByIDParams{
+		Content: sql.NullString{
+			String: content,
+			Valid: true,
+		},
+		UpdatedAt: time.Now().UTC(),
+		ID: postID,
+	}
+	err = s.db.UpdatePostContentByID(ctx, params)
+	if err != nil {
+		return fmt.Errorf("error updating post content: %v", err)
+	}
+	return nil
+}
+
+func postBody(post database.GetXPostsByUserIDRow) string {
+	if post.Content.String != "" {
+		return post.Content.String
+	}
+	return post.Description.String
+}
+
+func HandlerFullContent(s *state, cmd command, user database.User) error {
+	if len(cmd.Arguments) < 2 {
+		return errors.New("usage: fullcontent <feed url> <on|off>")
+	}
+	var enabled bool
+	switch strings.ToLower(cmd.Arguments[1]) {
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		return fmt.Errorf("unknown full content mode: %v (expected on or off)", cmd.Arguments[1])
+	}
+	feed, err := userFeedByUrl(context.Background(), s, user, cmd.Arguments[0])
+	if err != nil {
+		return err
+	}
+	params := database.SetFeedFullContentByIDParams{
+		FetchFullContent: enabled,
+		UpdatedAt: time.Now().UTC(),
+		ID: feed.ID,
+	}
+	err = s.db.SetFeedFullContentByID(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("error updating feed: %v", err)
+	}
+	if enabled {
+		fmt.Printf("full article content will be fetched for new posts from %v\n", feed.Url)
+	} else {
+		fmt.Printf("full article content will no longer be fetched for %v\n", feed.Url)
+	}
+	return nil
+}
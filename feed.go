@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+func parseFeed(data []byte, contentType string) (*RSSFeed, error) {
+	if isJSONFeed(contentType, data) {
+		return parseJSONFeed(data)
+	}
+	root, err := feedRootElement(data)
+	if err != nil {
+		return nil, err
+	}
+	switch root.Local {
+	case "rss":
+		var feed RSSFeed
+		err := xml.Unmarshal(data, &feed)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing rss feed: %v", err)
+		}
+		for idx, item := range feed.Channel.Item {
+			if item.PubDate == "" {
+				feed.Channel.Item[idx].PubDate = item.DCDate
+			}
+			if item.Author == "" {
+				feed.Channel.Item[idx].Author = item.DCCreator
+			}
+			if item.Description == "" {
+				feed.Channel.Item[idx].Description = item.ITunesSummary
+			}
+			feed.Channel.Item[idx].Enclosures = podcastEnclosures(item)
+		}
+		return &feed, nil
+	case "feed":
+		return parseAtomFeed(data)
+	case "RDF":
+		return parseRDFFeed(data)
+	default:
+		return nil, fmt.Errorf("unsupported feed format: <%v>", root.Local)
+	}
+}
+
+func feedRootElement(data []byte) (xml.Name, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return xml.Name{}, errors.New("no root element found in feed")
+		}
+		if err != nil {
+			return xml.Name{}, fmt.Errorf("error reading feed root element: %v", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Lynn-Xy/bloggatog/internal/database"
+	"github.com/google/uuid"
+)
+
+var filterMatchTypes = map[string]bool{
+	"keyword": true,
+	"regex": true,
+	"author": true,
+	"category": true,
+}
+
+var filterActions = map[string]bool{
+	"hide": true,
+	"read": true,
+}
+
+func filterRuleByArg(ctx context.Context, s *state, user database.User, arg string) (database.FilterRule, error) {
+	id, err := uuid.Parse(arg)
+	if err != nil {
+		return database.FilterRule{}, fmt.Errorf("invalid filter id: %v", arg)
+	}
+	rule, err := s.db.GetFilterRuleByID(ctx, database.GetFilterRuleByIDParams{
+		ID: id,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.FilterRule{}, fmt.Errorf("no filter with id: %v", arg)
+	}
+	if err != nil {
+		return database.FilterRule{}, fmt.Errorf("error retrieving filter from database: %v", err)
+	}
+	return rule, nil
+}
+
+func parseFilterRule(ctx context.Context, s *state, user database.User, name string, arguments []string) (database.CreateFilterRuleParams, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	feedURL := flags.String("feed", "", "only apply the rule to the feed with this url")
+	action := flags.String("action", "hide", "what to do with matching posts: hide or read")
+	args, err := parseArguments(flags, arguments)
+	if err != nil {
+		return database.CreateFilterRuleParams{}, fmt.Errorf("error parsing filter flags: %v", err)
+	}
+	if len(args) < 2 {
+		return database.CreateFilterRuleParams{}, errors.New("match type and pattern must be provided")
+	}
+	matchType := strings.ToLower(args[0])
+	pattern := strings.Join(args[1:], " ")
+	if !filterMatchTypes[matchType] {
+		return database.CreateFilterRuleParams{}, fmt.Errorf("unknown match type: %v (expected keyword, regex, author or category)", args[0])
+	}
+	if !filterActions[*action] {
+		return database.CreateFilterRuleParams{}, fmt.Errorf("unknown filter action: %v (expected hide or read)", *action)
+	}
+	if matchType == "regex" {
+		err := s.db.ValidateRegexPattern(ctx, pattern)
+		if err != nil {
+			return database.CreateFilterRuleParams{}, fmt.Errorf("invalid regex pattern: %v", err)
+		}
+	}
+	params := database.CreateFilterRuleParams{
+		ID: uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID: user.ID,
+		MatchType: matchType,
+		Pattern: pattern,
+		Action: *action,
+	}
+	if *feedURL != "" {
+		feed, err := userFeedByUrl(ctx, s, user, *feedURL)
+		if err != nil {
+			return database.CreateFilterRuleParams{}, err
+		}
+		params.FeedID = uuid.NullUUID{
+			UUID: feed.ID,
+			Valid: true,
+		}
+	}
+	return params, nil
+}
+
+func addFilterRule(ctx context.Context, s *state, user database.User, arguments []string) error {
+	params, err := parseFilterRule(ctx, s, user, "filter add", arguments)
+	if err != nil {
+		return err
+	}
+	rule, err := s.db.CreateFilterRule(ctx, params)
+	if err != nil {
+		return fmt.Errorf("error creating filter in database: %v", err)
+	}
+	fmt.Printf("created filter %v: %v %v %q\n", rule.ID, rule.Action, rule.MatchType, rule.Pattern)
+	return nil
+}
+
+func testFilterRule(ctx context.Context, s *state, user database.User, arguments []string) error {
+	if len(arguments) < 1 {
+		return errors.New("filter id, or match type and pattern, must be provided")
+	}
+	params := database.GetPostsMatchingFilterParams{
+		UserID: user.ID,
+		PostLimit: 20,
+	}
+	if len(arguments) == 1 {
+		rule, err := filterRuleByArg(ctx, s, user, arguments[0])
+		if err != nil {
+			return err
+		}
+		params.FeedID = rule.FeedID
+		params.MatchType = rule.MatchType
+		params.Pattern = rule.Pattern
+	} else {
+		rule, err := parseFilterRule(ctx, s, user, "filter test", arguments)
+		if err != nil {
+			return err
+		}
+		params.FeedID = rule.FeedID
+		params.MatchType = rule.MatchType
+		params.Pattern = rule.Pattern
+	}
+	posts, err := s.db.GetPostsMatchingFilter(ctx, params)
+	if err != nil {
+		return fmt.Errorf("error testing filter: %v", err)
+	}
+	if len(posts) == 0 {
+		fmt.Println("no posts match this filter")
+		return nil
+	}
+	for _, post := range posts {
+		fmt.Printf("* %v (%v): %v\n", post.Title.String, post.FeedName.String, post.Url)
+	}
+	return nil
+}
+
+func HandlerFilter(s *state, cmd command, user database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return errors.New("usage: filter list | add [--feed url] [--action hide|read] <keyword|regex|author|category> <pattern> | test <id> | test [--feed url] <keyword|regex|author|category> <pattern> | delete <id>")
+	}
+	ctx := context.Background()
+	args := cmd.Arguments[1:]
+	switch cmd.Arguments[0] {
+	case "list":
+		rules, err := s.db.GetFilterRulesByUserID(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("error retrieving filters from database: %v", err)
+		}
+		for _, rule := range rules {
+			scope := "all feeds"
+			if rule.FeedUrl.Valid {
+				scope = rule.FeedUrl.String
+			}
+			fmt.Printf("* %v: %v %v %q (%v)\n", rule.ID, rule.Action, rule.MatchType, rule.Pattern, scope)
+		}
+	case "add":
+		return addFilterRule(ctx, s, user, args)
+	case "test":
+		return testFilterRule(ctx, s, user, args)
+	case "delete":
+		if len(args) < 1 {
+			return errors.New("filter id must be provided")
+		}
+		rule, err := filterRuleByArg(ctx, s, user, args[0])
+		if err != nil {
+			return err
+		}
+		_, err = s.db.DeleteFilterRuleByID(ctx, database.DeleteFilterRuleByIDParams{
+			ID: rule.ID,
+			UserID: user.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("error deleting filter: %v", err)
+		}
+		fmt.Printf("deleted filter %v\n", rule.ID)
+	default:
+		return fmt.Errorf("unknown filter subcommand: %v", cmd.Arguments[0])
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Lynn-Xy/bloggatog/internal/database"
+	"github.com/google/uuid"
+)
+
+func folderByName(ctx context.Context, s *state, user database.User, name string) (database.Folder, error) {
+	folder, err := s.db.GetFolderByName(ctx, database.GetFolderByNameParams{
+		UserID: user.ID,
+		Name: name,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Folder{}, &notFoundError{fmt.Sprintf("no folder named: %v", name)}
+	}
+	if err != nil {
+		return database.Folder{}, fmt.Errorf("error retrieving folder from database: %v", err)
+	}
+	return folder, nil
+}
+
+func getOrCreateFolder(ctx context.Context, s *state, user database.User, name string) (uuid.NullUUID, error) {
+	if name == "" {
+		return uuid.NullUUID{}, nil
+	}
+	folder, err := s.db.GetOrCreateFolder(ctx, database.GetOrCreateFolderParams{
+		ID: uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID: user.ID,
+		Name: name,
+	})
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("error creating folder in database: %v", err)
+	}
+	return uuid.NullUUID{UUID: folder.ID, Valid: true}, nil
+}
+
+func setFollowFolder(ctx context.Context, s *state, user database.User, feedURL string, folderID uuid.NullUUID) error {
+	feed, err := s.db.GetFeedByUrl(ctx, feedURL)
+	if err != nil {
+		return fmt.Errorf("error retrieving feed from database: %v", err)
+	}
+	updated, err := s.db.SetFeedFollowFolder(ctx, database.SetFeedFollowFolderParams{
+		FolderID: folderID,
+		UpdatedAt: time.Now().UTC(),
+		UserID: user.ID,
+		FeedID: feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("error updating feed_follow folder: %v", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("not following feed: %v", feedURL)
+	}
+	return nil
+}
+
+func HandlerFolder(s *state, cmd command, user database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return errors.New("usage: folder list | create <name> | rename <old> <new> | assign <name> <feed url> | unassign <feed url> | remove <name>")
+	}
+	ctx := context.Background()
+	args := cmd.Arguments[1:]
+	switch cmd.Arguments[0] {
+	case "list":
+		folders, err := s.db.GetFoldersByUserID(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("error retrieving folders from database: %v", err)
+		}
+		for _, folder := range folders {
+			fmt.Printf("* %v\n", folder.Name)
+		}
+	case "create":
+		if len(args) < 1 {
+			return errors.New("folder name must be provided")
+		}
+		_, err := s.db.CreateFolder(ctx, database.CreateFolderParams{
+			ID: uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			UserID: user.ID,
+			Name: args[0],
+		})
+		if err != nil {
+			return fmt.Errorf("error creating folder in database: %v", err)
+		}
+		fmt.Printf("created folder: %v\n", args[0])
+	case "rename":
+		if len(args) < 2 {
+			return errors.New("current and new folder names must be provided")
+		}
+		folder, err := folderByName(ctx, s, user, args[0])
+		if err != nil {
+			return err
+		}
+		err = s.db.RenameFolderByID(ctx, database.RenameFolderByIDParams{
+			Name: args[1],
+			UpdatedAt: time.Now().UTC(),
+			ID: folder.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("error renaming folder: %v", err)
+		}
+		fmt.Printf("renamed folder %v to %v\n", args[0], args[1])
+	case "assign":
+		if len(args) < 2 {
+			return errors.New("folder name and feed url must be provided")
+		}
+		folder, err := folderByName(ctx, s, user, args[0])
+		if err != nil {
+			return err
+		}
+		err = setFollowFolder(ctx, s, user, args[1], uuid.NullUUID{UUID: folder.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("moved %v to folder %v\n", args[1], folder.Name)
+	case "unassign":
+		if len(args) < 1 {
+			return errors.New("feed url must be provided")
+		}
+		err := setFollowFolder(ctx, s, user, args[0], uuid.NullUUID{})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("removed %v from its folder\n", args[0])
+	case "remove":
+		if len(args) < 1 {
+			return errors.New("folder name must be provided")
+		}
+		folder, err := folderByName(ctx, s, user, args[0])
+		if err != nil {
+			return err
+		}
+		err = s.db.DeleteFolderByID(ctx, folder.ID)
+		if err != nil {
+			return fmt.Errorf("error deleting folder: %v", err)
+		}
+		fmt.Printf("removed folder %v, its feeds are now unfiled\n", folder.Name)
+	default:
+		return fmt.Errorf("unknown folder subcommand: %v", cmd.Arguments[0])
+	}
+	return nil
+}
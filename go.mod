@@ -3,6 +3,10 @@ module github.com/Lynn-Xy/bloggatog
 go 1.24.0
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
+	golang.org/x/net v0.50.0
+	golang.org/x/term v0.40.0
 )
+
+require golang.org/x/sys v0.41.0 // indirect
@@ -10,6 +10,7 @@ import (
 type Config struct {
 	DBURL string `json:"db_url"`
 	CurrentUsername string `json:"current_user_name"`
+	DownloadDir string `json:"download_dir,omitempty"`
 }
 
 func Read() (Config, error) {
@@ -34,6 +35,15 @@ func Read() (Config, error) {
 
 func (c *Config) SetUser(username string) error {
 	c.CurrentUsername = username
+	return c.write()
+}
+
+func (c *Config) SetDownloadDir(dir string) error {
+	c.DownloadDir = dir
+	return c.write()
+}
+
+func (c *Config) write() error {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return fmt.Errorf("error getting user home directory: %v", err)
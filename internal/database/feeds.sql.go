@@ -13,17 +13,107 @@ import (
 	"github.com/google/uuid"
 )
 
+const claimDueFeeds = `-- name: ClaimDueFeeds :many
+UPDATE feeds
+SET last_fetched_at = $1, updated_at = $1, next_fetch_at = $2
+WHERE id IN (
+    SELECT id
+    FROM feeds
+    WHERE EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = feeds.id
+    )
+    AND disabled_at IS NULL
+    AND (next_fetch_at IS NULL OR next_fetch_at <= $1)
+    ORDER BY next_fetch_at ASC NULLS FIRST
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, etag, last_modified, fetch_full_content, legacy_guids
+`
+
+type ClaimDueFeedsParams struct {
+	FetchedAt  sql.NullTime
+	LeaseUntil sql.NullTime
+	BatchSize  int32
+}
+
+type ClaimDueFeedsRow struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Name             sql.NullString
+	Url              string
+	UserID           uuid.UUID
+	Etag             sql.NullString
+	LastModified     sql.NullString
+	FetchFullContent bool
+	LegacyGuids      bool
+}
+
+func (q *Queries) ClaimDueFeeds(ctx context.Context, arg ClaimDueFeedsParams) ([]ClaimDueFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, claimDueFeeds, arg.FetchedAt, arg.LeaseUntil, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimDueFeedsRow
+	for rows.Next() {
+		var i ClaimDueFeedsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.Etag,
+			&i.LastModified,
+			&i.FetchFullContent,
+			&i.LegacyGuids,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const clearFeedLegacyGuidsByID = `-- name: ClearFeedLegacyGuidsByID :exec
+UPDATE feeds
+SET legacy_guids = false, updated_at = $1
+WHERE id = $2
+`
+
+type ClearFeedLegacyGuidsByIDParams struct {
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) ClearFeedLegacyGuidsByID(ctx context.Context, arg ClearFeedLegacyGuidsByIDParams) error {
+	_, err := q.db.ExecContext(ctx, clearFeedLegacyGuidsByID, arg.UpdatedAt, arg.ID)
+	return err
+}
+
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, site_url)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, last_status, last_error, consecutive_failures, disabled_at, site_url, fetch_full_content, legacy_guids
 `
 
 type CreateFeedParams struct {
@@ -33,6 +123,7 @@ type CreateFeedParams struct {
 	Name      sql.NullString
 	Url       string
 	UserID    uuid.UUID
+	SiteUrl   sql.NullString
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -43,6 +134,7 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.SiteUrl,
 	)
 	var i Feed
 	err := row.Scan(
@@ -53,10 +145,53 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.LastStatus,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
+		&i.SiteUrl,
+		&i.FetchFullContent,
+		&i.LegacyGuids,
 	)
 	return i, err
 }
 
+const disableFeedByID = `-- name: DisableFeedByID :exec
+UPDATE feeds
+SET disabled_at = $1, next_fetch_at = NULL, updated_at = $2
+WHERE id = $3
+`
+
+type DisableFeedByIDParams struct {
+	DisabledAt sql.NullTime
+	UpdatedAt  time.Time
+	ID         uuid.UUID
+}
+
+func (q *Queries) DisableFeedByID(ctx context.Context, arg DisableFeedByIDParams) error {
+	_, err := q.db.ExecContext(ctx, disableFeedByID, arg.DisabledAt, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const enableFeedByID = `-- name: EnableFeedByID :exec
+UPDATE feeds
+SET disabled_at = NULL, consecutive_failures = 0, next_fetch_at = NULL, updated_at = $1
+WHERE id = $2
+`
+
+type EnableFeedByIDParams struct {
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) EnableFeedByID(ctx context.Context, arg EnableFeedByIDParams) error {
+	_, err := q.db.ExecContext(ctx, enableFeedByID, arg.UpdatedAt, arg.ID)
+	return err
+}
+
 const getAllFeeds = `-- name: GetAllFeeds :many
 SELECT id, created_at, updated_at, name, url, user_id
 FROM feeds
@@ -143,15 +278,127 @@ func (q *Queries) GetFeedNameByFeedID(ctx context.Context, id uuid.UUID) (sql.Nu
 	return name, err
 }
 
-const getNextFeedToFetchByUserID = `-- name: GetNextFeedToFetchByUserID :one
+const getFeedStatuses = `-- name: GetFeedStatuses :many
+SELECT id, name, url, last_fetched_at, next_fetch_at, last_status, last_error, consecutive_failures, disabled_at
+FROM feeds
+ORDER BY name ASC
+`
+
+type GetFeedStatusesRow struct {
+	ID                  uuid.UUID
+	Name                sql.NullString
+	Url                 string
+	LastFetchedAt       sql.NullTime
+	NextFetchAt         sql.NullTime
+	LastStatus          sql.NullInt32
+	LastError           sql.NullString
+	ConsecutiveFailures int32
+	DisabledAt          sql.NullTime
+}
+
+func (q *Queries) GetFeedStatuses(ctx context.Context) ([]GetFeedStatusesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedStatuses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedStatusesRow
+	for rows.Next() {
+		var i GetFeedStatusesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.LastFetchedAt,
+			&i.NextFetchAt,
+			&i.LastStatus,
+			&i.LastError,
+			&i.ConsecutiveFailures,
+			&i.DisabledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnhealthyFeeds = `-- name: GetUnhealthyFeeds :many
+SELECT id, name, url, last_fetched_at, next_fetch_at, last_status, last_error, consecutive_failures, disabled_at
+FROM feeds
+WHERE consecutive_failures > 0 OR disabled_at IS NOT NULL
+ORDER BY disabled_at ASC NULLS LAST, consecutive_failures DESC
+`
+
+type GetUnhealthyFeedsRow struct {
+	ID                  uuid.UUID
+	Name                sql.NullString
+	Url                 string
+	LastFetchedAt       sql.NullTime
+	NextFetchAt         sql.NullTime
+	LastStatus          sql.NullInt32
+	LastError           sql.NullString
+	ConsecutiveFailures int32
+	DisabledAt          sql.NullTime
+}
+
+func (q *Queries) GetUnhealthyFeeds(ctx context.Context) ([]GetUnhealthyFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUnhealthyFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUnhealthyFeedsRow
+	for rows.Next() {
+		var i GetUnhealthyFeedsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.LastFetchedAt,
+			&i.NextFetchAt,
+			&i.LastStatus,
+			&i.LastError,
+			&i.ConsecutiveFailures,
+			&i.DisabledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserFeedByUrl = `-- name: GetUserFeedByUrl :one
 SELECT id, created_at, updated_at, name, url, user_id
 FROM feeds
-WHERE user_id = $1
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1
+WHERE url = $1
+AND (user_id = $2 OR EXISTS (
+    SELECT 1
+    FROM feed_follows
+    WHERE feed_follows.feed_id = feeds.id
+    AND feed_follows.user_id = $2
+))
 `
 
-type GetNextFeedToFetchByUserIDRow struct {
+type GetUserFeedByUrlParams struct {
+	Url    string
+	UserID uuid.UUID
+}
+
+type GetUserFeedByUrlRow struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -160,9 +407,9 @@ type GetNextFeedToFetchByUserIDRow struct {
 	UserID    uuid.UUID
 }
 
-func (q *Queries) GetNextFeedToFetchByUserID(ctx context.Context, userID uuid.UUID) (GetNextFeedToFetchByUserIDRow, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetchByUserID, userID)
-	var i GetNextFeedToFetchByUserIDRow
+func (q *Queries) GetUserFeedByUrl(ctx context.Context, arg GetUserFeedByUrlParams) (GetUserFeedByUrlRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserFeedByUrl, arg.Url, arg.UserID)
+	var i GetUserFeedByUrlRow
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
@@ -190,3 +437,126 @@ func (q *Queries) MarkFeedFetchedByID(ctx context.Context, arg MarkFeedFetchedBy
 	_, err := q.db.ExecContext(ctx, markFeedFetchedByID, arg.LastFetchedAt, arg.UpdatedAt, arg.ID)
 	return err
 }
+
+const recordFeedFailureByID = `-- name: RecordFeedFailureByID :one
+UPDATE feeds
+SET last_status = $1, last_error = $2, consecutive_failures = consecutive_failures + 1, updated_at = $3
+WHERE id = $4
+RETURNING consecutive_failures
+`
+
+type RecordFeedFailureByIDParams struct {
+	LastStatus sql.NullInt32
+	LastError  sql.NullString
+	UpdatedAt  time.Time
+	ID         uuid.UUID
+}
+
+func (q *Queries) RecordFeedFailureByID(ctx context.Context, arg RecordFeedFailureByIDParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, recordFeedFailureByID,
+		arg.LastStatus,
+		arg.LastError,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var consecutiveFailures int32
+	err := row.Scan(&consecutiveFailures)
+	return consecutiveFailures, err
+}
+
+const recordFeedSuccessByID = `-- name: RecordFeedSuccessByID :exec
+UPDATE feeds
+SET last_status = $1, last_error = NULL, consecutive_failures = 0, next_fetch_at = $2, updated_at = $3
+WHERE id = $4
+`
+
+type RecordFeedSuccessByIDParams struct {
+	LastStatus  sql.NullInt32
+	NextFetchAt sql.NullTime
+	UpdatedAt   time.Time
+	ID          uuid.UUID
+}
+
+func (q *Queries) RecordFeedSuccessByID(ctx context.Context, arg RecordFeedSuccessByIDParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedSuccessByID,
+		arg.LastStatus,
+		arg.NextFetchAt,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const setFeedFullContentByID = `-- name: SetFeedFullContentByID :exec
+UPDATE feeds
+SET fetch_full_content = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetFeedFullContentByIDParams struct {
+	FetchFullContent bool
+	UpdatedAt        time.Time
+	ID               uuid.UUID
+}
+
+func (q *Queries) SetFeedFullContentByID(ctx context.Context, arg SetFeedFullContentByIDParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFullContentByID, arg.FetchFullContent, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setFeedNextFetchByID = `-- name: SetFeedNextFetchByID :exec
+UPDATE feeds
+SET next_fetch_at = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetFeedNextFetchByIDParams struct {
+	NextFetchAt sql.NullTime
+	UpdatedAt   time.Time
+	ID          uuid.UUID
+}
+
+func (q *Queries) SetFeedNextFetchByID(ctx context.Context, arg SetFeedNextFetchByIDParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedNextFetchByID, arg.NextFetchAt, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const updateFeedCacheHeadersByID = `-- name: UpdateFeedCacheHeadersByID :exec
+UPDATE feeds
+SET etag = $1, last_modified = $2, updated_at = $3
+WHERE id = $4
+`
+
+type UpdateFeedCacheHeadersByIDParams struct {
+	Etag         sql.NullString
+	LastModified sql.NullString
+	UpdatedAt    time.Time
+	ID           uuid.UUID
+}
+
+func (q *Queries) UpdateFeedCacheHeadersByID(ctx context.Context, arg UpdateFeedCacheHeadersByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedCacheHeadersByID,
+		arg.Etag,
+		arg.LastModified,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const updateFeedSiteUrlByID = `-- name: UpdateFeedSiteUrlByID :exec
+UPDATE feeds
+SET site_url = $1, updated_at = $2
+WHERE id = $3
+`
+
+type UpdateFeedSiteUrlByIDParams struct {
+	SiteUrl   sql.NullString
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) UpdateFeedSiteUrlByID(ctx context.Context, arg UpdateFeedSiteUrlByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedSiteUrlByID, arg.SiteUrl, arg.UpdatedAt, arg.ID)
+	return err
+}
@@ -20,9 +20,9 @@ WITH inserted_feed_follow AS (
         $1,
         $2
     )
-RETURNING id, created_at, updated_at, user_id, feed_id
+RETURNING id, created_at, updated_at, user_id, feed_id, folder_id
 )
-SELECT inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id,
+SELECT inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.folder_id,
     feeds.name AS feed_name,
     users.name AS user_name
 FROM inserted_feed_follow
@@ -41,6 +41,7 @@ type CreateFeedFollowRow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	FolderID  uuid.NullUUID
 	FeedName  sql.NullString
 	UserName  string
 }
@@ -54,14 +55,39 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.FolderID,
 		&i.FeedName,
 		&i.UserName,
 	)
 	return i, err
 }
 
+const createFeedFollowIfMissing = `-- name: CreateFeedFollowIfMissing :execrows
+INSERT INTO feed_follows (user_id, feed_id, folder_id)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id, feed_id) DO NOTHING
+`
+
+type CreateFeedFollowIfMissingParams struct {
+	UserID   uuid.UUID
+	FeedID   uuid.UUID
+	FolderID uuid.NullUUID
+}
+
+func (q *Queries) CreateFeedFollowIfMissing(ctx context.Context, arg CreateFeedFollowIfMissingParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createFeedFollowIfMissing, arg.UserID, arg.FeedID, arg.FolderID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getFeedFollowForUser = `-- name: GetFeedFollowForUser :many
-SELECT id, created_at, updated_at, user_id, feed_id
+SELECT id, created_at, updated_at, user_id, feed_id, folder_id
 FROM feed_follows
 WHERE user_id = $1
 `
@@ -81,6 +107,7 @@ func (q *Queries) GetFeedFollowForUser(ctx context.Context, userID uuid.UUID) ([
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.FolderID,
 		); err != nil {
 			return nil, err
 		}
@@ -95,6 +122,78 @@ func (q *Queries) GetFeedFollowForUser(ctx context.Context, userID uuid.UUID) ([
 	return items, nil
 }
 
+const getFollowedFeedsByUserID = `-- name: GetFollowedFeedsByUserID :many
+SELECT feeds.name, feeds.url, feeds.site_url, folders.name AS folder_name, feed_follows.feed_id
+FROM feed_follows
+INNER JOIN feeds ON feed_follows.feed_id = feeds.id
+LEFT JOIN folders ON feed_follows.folder_id = folders.id
+WHERE feed_follows.user_id = $1
+ORDER BY folders.name ASC NULLS FIRST, feeds.name ASC
+`
+
+type GetFollowedFeedsByUserIDRow struct {
+	Name       sql.NullString
+	Url        string
+	SiteUrl    sql.NullString
+	FolderName sql.NullString
+	FeedID     uuid.UUID
+}
+
+func (q *Queries) GetFollowedFeedsByUserID(ctx context.Context, userID uuid.UUID) ([]GetFollowedFeedsByUserIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeedsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFollowedFeedsByUserIDRow
+	for rows.Next() {
+		var i GetFollowedFeedsByUserIDRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.SiteUrl,
+			&i.FolderName,
+			&i.FeedID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setFeedFollowFolder = `-- name: SetFeedFollowFolder :execrows
+UPDATE feed_follows
+SET folder_id = $1, updated_at = $2
+WHERE user_id = $3 AND feed_id = $4
+`
+
+type SetFeedFollowFolderParams struct {
+	FolderID  uuid.NullUUID
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+}
+
+func (q *Queries) SetFeedFollowFolder(ctx context.Context, arg SetFeedFollowFolderParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedFollowFolder,
+		arg.FolderID,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.FeedID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const unfollowFeedByID = `-- name: UnfollowFeedByID :exec
 DELETE FROM feed_follows
 WHERE user_id = $1 AND feed_id = $2
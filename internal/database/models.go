@@ -11,14 +11,54 @@ import (
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UserID     uuid.UUID
+	Name       string
+	KeyHash    string
+	LastUsedAt sql.NullTime
+}
+
+type Attachment struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	PostID          uuid.UUID
+	Url             string
+	MimeType        sql.NullString
+	Length          sql.NullInt64
+	DurationSeconds sql.NullInt32
+}
+
+type AttachmentDownload struct {
+	UserID          uuid.UUID
+	AttachmentID    uuid.UUID
+	UpdatedAt       time.Time
+	Path            string
+	BytesDownloaded int64
+	CompletedAt     sql.NullTime
+	LastError       sql.NullString
+}
+
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          sql.NullString
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Name                sql.NullString
+	Url                 string
+	UserID              uuid.UUID
+	LastFetchedAt       sql.NullTime
+	Etag                sql.NullString
+	LastModified        sql.NullString
+	NextFetchAt         sql.NullTime
+	LastStatus          sql.NullInt32
+	LastError           sql.NullString
+	ConsecutiveFailures int32
+	DisabledAt          sql.NullTime
+	SiteUrl             sql.NullString
+	FetchFullContent    bool
+	LegacyGuids         bool
 }
 
 type FeedFollow struct {
@@ -27,17 +67,76 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	FolderID  uuid.NullUUID
+}
+
+type FilterRule struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    uuid.NullUUID
+	MatchType string
+	Pattern   string
+	Action    string
+}
+
+type Folder struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
 }
 
 type Post struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Title        sql.NullString
+	Url          string
+	Description  sql.NullString
+	PublishedAt  sql.NullTime
+	FeedID       uuid.UUID
+	Author       sql.NullString
+	PublishedRaw sql.NullString
+	Categories   []string
+	Content      sql.NullString
+	SearchVector interface{}
+	Guid         string
+	ContentHash  sql.NullString
+	EditedAt     sql.NullTime
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostRevision struct {
 	ID          uuid.UUID
 	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	PostID      uuid.UUID
 	Title       sql.NullString
 	Url         string
 	Description sql.NullString
-	PublishedAt time.Time
-	FeedID      uuid.UUID
+	Author      sql.NullString
+	Categories  []string
+	ContentHash sql.NullString
+	Content     sql.NullString
+}
+
+type PostStar struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	StarredAt time.Time
+}
+
+type RiverToken struct {
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	TokenHash string
 }
 
 type User struct {
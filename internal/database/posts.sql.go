@@ -11,45 +11,51 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES (
-    $1,
-    $2,
-    $3,
-    $4,
-    $5,
-    $6,
-    $7,
-    $8
-    )
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+const adoptLegacyPostGuid = `-- name: AdoptLegacyPostGuid :execrows
+UPDATE posts
+SET guid = $1
+WHERE feed_id = $2
+AND url = $3
+AND guid = url
+AND NOT EXISTS (
+    SELECT 1
+    FROM posts AS existing
+    WHERE existing.feed_id = $2
+    AND existing.guid = $1
+)
 `
 
-type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       sql.NullString
-	Url         string
-	Description sql.NullString
-	PublishedAt time.Time
-	FeedID      uuid.UUID
+type AdoptLegacyPostGuidParams struct {
+	Guid   string
+	FeedID uuid.UUID
+	Url    string
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
-		arg.ID,
-		arg.CreatedAt,
-		arg.UpdatedAt,
-		arg.Title,
-		arg.Url,
-		arg.Description,
-		arg.PublishedAt,
-		arg.FeedID,
-	)
+func (q *Queries) AdoptLegacyPostGuid(ctx context.Context, arg AdoptLegacyPostGuidParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, adoptLegacyPostGuid, arg.Guid, arg.FeedID, arg.Url)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFollowedPostByID = `-- name: GetFollowedPostByID :one
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.published_raw, posts.categories, posts.content, posts.search_vector, posts.guid, posts.content_hash, posts.edited_at
+FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE posts.id = $1 AND feed_follows.user_id = $2
+`
+
+type GetFollowedPostByIDParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetFollowedPostByID(ctx context.Context, arg GetFollowedPostByIDParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getFollowedPostByID, arg.ID, arg.UserID)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -60,34 +66,234 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Author,
+		&i.PublishedRaw,
+		pq.Array(&i.Categories),
+		&i.Content,
+		&i.SearchVector,
+		&i.Guid,
+		&i.ContentHash,
+		&i.EditedAt,
 	)
 	return i, err
 }
 
+const getFollowedPostsByUrl = `-- name: GetFollowedPostsByUrl :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.published_raw, posts.categories, posts.content, posts.search_vector, posts.guid, posts.content_hash, posts.edited_at
+FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE posts.url = $1 AND feed_follows.user_id = $2
+ORDER BY posts.created_at ASC
+`
+
+type GetFollowedPostsByUrlParams struct {
+	Url    string
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetFollowedPostsByUrl(ctx context.Context, arg GetFollowedPostsByUrlParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedPostsByUrl, arg.Url, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Author,
+			&i.PublishedRaw,
+			pq.Array(&i.Categories),
+			&i.Content,
+			&i.SearchVector,
+			&i.Guid,
+			&i.ContentHash,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostRevisionsByPostID = `-- name: GetPostRevisionsByPostID :many
+SELECT id, created_at, post_id, title, url, description, author, categories, content_hash, content
+FROM post_revisions
+WHERE post_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetPostRevisionsByPostID(ctx context.Context, postID uuid.UUID) ([]PostRevision, error) {
+	rows, err := q.db.QueryContext(ctx, getPostRevisionsByPostID, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostRevision
+	for rows.Next() {
+		var i PostRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.PostID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.ContentHash,
+			&i.Content,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecentPostDatesByFeedID = `-- name: GetRecentPostDatesByFeedID :many
+SELECT COALESCE(published_at, created_at)::timestamp AS post_date
+FROM posts
+WHERE feed_id = $1
+ORDER BY post_date DESC
+LIMIT $2
+`
+
+type GetRecentPostDatesByFeedIDParams struct {
+	FeedID uuid.UUID
+	Limit  int32
+}
+
+func (q *Queries) GetRecentPostDatesByFeedID(ctx context.Context, arg GetRecentPostDatesByFeedIDParams) ([]time.Time, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentPostDatesByFeedID, arg.FeedID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []time.Time
+	for rows.Next() {
+		var postDate time.Time
+		if err := rows.Scan(&postDate); err != nil {
+			return nil, err
+		}
+		items = append(items, postDate)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getXPostsByUserID = `-- name: GetXPostsByUserID :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.published_raw, posts.categories, posts.content, posts.edited_at, post_reads.read_at, COALESCE(filters.muted, false)::boolean AS muted, feeds.name AS feed_name, post_stars.starred_at
 FROM posts
 INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds ON posts.feed_id = feeds.id
 INNER JOIN users ON feed_follows.user_id = users.id
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = users.id
+LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = users.id
+LEFT JOIN LATERAL (
+    SELECT bool_or(filter_rules.action = 'hide') AS hidden, bool_or(filter_rules.action = 'read') AS muted
+    FROM filter_rules
+    WHERE filter_rules.user_id = users.id
+    AND (filter_rules.feed_id IS NULL OR filter_rules.feed_id = posts.feed_id)
+    AND CASE filter_rules.match_type
+        WHEN 'keyword' THEN strpos(lower(COALESCE(posts.title, '') || ' ' || COALESCE(posts.description, '')), lower(filter_rules.pattern)) > 0
+        WHEN 'regex' THEN COALESCE(posts.title, '') || ' ' || COALESCE(posts.description, '') ~* filter_rules.pattern
+        WHEN 'author' THEN lower(COALESCE(posts.author, '')) = lower(filter_rules.pattern)
+        WHEN 'category' THEN EXISTS (
+            SELECT 1
+            FROM unnest(posts.categories) AS category
+            WHERE lower(category) = lower(filter_rules.pattern)
+        )
+        ELSE false
+    END
+) AS filters ON true
 WHERE users.id = $1
-ORDER BY published_at DESC
-LIMIT $2
+AND NOT COALESCE(filters.hidden, false)
+AND (NOT $2::boolean OR (post_reads.read_at IS NULL AND NOT COALESCE(filters.muted, false)))
+AND ($3::uuid IS NULL OR feed_follows.folder_id = $3)
+AND ($4::uuid IS NULL OR posts.feed_id = $4)
+AND ($5::text IS NULL OR posts.search_vector @@ websearch_to_tsquery('english', $5))
+AND (NOT $6::boolean OR posts.edited_at IS NOT NULL)
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC
+LIMIT $7
+OFFSET $8
 `
 
 type GetXPostsByUserIDParams struct {
-	ID    uuid.UUID
-	Limit int32
+	ID         uuid.UUID
+	UnreadOnly bool
+	FolderID   uuid.NullUUID
+	FeedID     uuid.NullUUID
+	Query      sql.NullString
+	EditedOnly bool
+	PostLimit  int32
+	PostOffset int32
 }
 
-func (q *Queries) GetXPostsByUserID(ctx context.Context, arg GetXPostsByUserIDParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getXPostsByUserID, arg.ID, arg.Limit)
+type GetXPostsByUserIDRow struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Title        sql.NullString
+	Url          string
+	Description  sql.NullString
+	PublishedAt  sql.NullTime
+	FeedID       uuid.UUID
+	Author       sql.NullString
+	PublishedRaw sql.NullString
+	Categories   []string
+	Content      sql.NullString
+	EditedAt     sql.NullTime
+	ReadAt       sql.NullTime
+	Muted        bool
+	FeedName     sql.NullString
+	StarredAt    sql.NullTime
+}
+
+func (q *Queries) GetXPostsByUserID(ctx context.Context, arg GetXPostsByUserIDParams) ([]GetXPostsByUserIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, getXPostsByUserID,
+		arg.ID,
+		arg.UnreadOnly,
+		arg.FolderID,
+		arg.FeedID,
+		arg.Query,
+		arg.EditedOnly,
+		arg.PostLimit,
+		arg.PostOffset,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []GetXPostsByUserIDRow
 	for rows.Next() {
-		var i Post
+		var i GetXPostsByUserIDRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
@@ -97,6 +303,15 @@ func (q *Queries) GetXPostsByUserID(ctx context.Context, arg GetXPostsByUserIDPa
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Author,
+			&i.PublishedRaw,
+			pq.Array(&i.Categories),
+			&i.Content,
+			&i.EditedAt,
+			&i.ReadAt,
+			&i.Muted,
+			&i.FeedName,
+			&i.StarredAt,
 		); err != nil {
 			return nil, err
 		}
@@ -110,3 +325,121 @@ func (q *Queries) GetXPostsByUserID(ctx context.Context, arg GetXPostsByUserIDPa
 	}
 	return items, nil
 }
+
+const updatePostContentByID = `-- name: UpdatePostContentByID :exec
+UPDATE posts
+SET content = $1, updated_at = $2
+WHERE id = $3
+`
+
+type UpdatePostContentByIDParams struct {
+	Content   sql.NullString
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) UpdatePostContentByID(ctx context.Context, arg UpdatePostContentByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updatePostContentByID, arg.Content, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const upsertPost = `-- name: UpsertPost :one
+WITH previous AS (
+    SELECT posts.id, posts.title, posts.url, posts.description, posts.author, posts.categories, posts.content_hash, posts.content
+    FROM posts
+    WHERE posts.feed_id = $8 AND posts.guid = $12
+),
+upserted AS (
+    INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, author, published_raw, categories, guid, content_hash)
+    VALUES (
+        $1,
+        $2,
+        $3,
+        $4,
+        $5,
+        $6,
+        $7,
+        $8,
+        $9,
+        $10,
+        $11,
+        $12,
+        $13
+        )
+    ON CONFLICT (feed_id, guid) DO UPDATE
+    SET updated_at = EXCLUDED.updated_at,
+        title = EXCLUDED.title,
+        url = EXCLUDED.url,
+        description = EXCLUDED.description,
+        author = EXCLUDED.author,
+        categories = EXCLUDED.categories,
+        content_hash = EXCLUDED.content_hash,
+        edited_at = CASE
+            WHEN posts.content_hash IS NOT NULL AND posts.content_hash <> EXCLUDED.content_hash THEN EXCLUDED.updated_at
+            ELSE posts.edited_at
+        END
+    WHERE (posts.title, posts.url, posts.description, posts.author, posts.categories, posts.content_hash)
+        IS DISTINCT FROM (EXCLUDED.title, EXCLUDED.url, EXCLUDED.description, EXCLUDED.author, EXCLUDED.categories, EXCLUDED.content_hash)
+    RETURNING posts.id, posts.url, (xmax = 0)::boolean AS inserted, posts.content_hash
+),
+revision AS (
+    INSERT INTO post_revisions (created_at, post_id, title, url, description, author, categories, content_hash, content)
+    SELECT $3, previous.id, previous.title, previous.url, previous.description, previous.author, previous.categories, previous.content_hash, previous.content
+    FROM previous
+    INNER JOIN upserted ON previous.id = upserted.id
+    WHERE previous.content_hash IS NOT NULL AND previous.content_hash <> upserted.content_hash
+)
+SELECT upserted.id, upserted.url, upserted.inserted,
+    COALESCE(previous.content_hash IS NOT NULL AND previous.content_hash <> upserted.content_hash, false)::boolean AS edited
+FROM upserted
+LEFT JOIN previous ON previous.id = upserted.id
+`
+
+type UpsertPostParams struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Title        sql.NullString
+	Url          string
+	Description  sql.NullString
+	PublishedAt  sql.NullTime
+	FeedID       uuid.UUID
+	Author       sql.NullString
+	PublishedRaw sql.NullString
+	Categories   []string
+	Guid         string
+	ContentHash  sql.NullString
+}
+
+type UpsertPostRow struct {
+	ID       uuid.UUID
+	Url      string
+	Inserted bool
+	Edited   bool
+}
+
+func (q *Queries) UpsertPost(ctx context.Context, arg UpsertPostParams) (UpsertPostRow, error) {
+	row := q.db.QueryRowContext(ctx, upsertPost,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.Author,
+		arg.PublishedRaw,
+		pq.Array(arg.Categories),
+		arg.Guid,
+		arg.ContentHash,
+	)
+	var i UpsertPostRow
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Inserted,
+		&i.Edited,
+	)
+	return i, err
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL string `json:"url"`
+}
+
+type jsonFeedAttachment struct {
+	URL string `json:"url"`
+	MimeType string `json:"mime_type"`
+	Title string `json:"title"`
+	SizeInBytes int64 `json:"size_in_bytes"`
+	DurationInSeconds float64 `json:"duration_in_seconds"`
+}
+
+type jsonFeedItem struct {
+	ID json.RawMessage `json:"id"`
+	URL string `json:"url"`
+	ExternalURL string `json:"external_url"`
+	Title string `json:"title"`
+	ContentHTML string `json:"content_html"`
+	ContentText string `json:"content_text"`
+	Summary string `json:"summary"`
+	DatePublished string `json:"date_published"`
+	DateModified string `json:"date_modified"`
+	Author *jsonFeedAuthor `json:"author"`
+	Authors []jsonFeedAuthor `json:"authors"`
+	Attachments []jsonFeedAttachment `json:"attachments"`
+	Tags []string `json:"tags"`
+}
+
+type jsonFeed struct {
+	Version string `json:"version"`
+	Title string `json:"title"`
+	HomePageURL string `json:"home_page_url"`
+	FeedURL string `json:"feed_url"`
+	Description string `json:"description"`
+	Items []jsonFeedItem `json:"items"`
+}
+
+func isJSONFeed(contentType string, data []byte) bool {
+	if strings.Contains(contentType, "json") {
+		return true
+	}
+	trimmed := strings.TrimSpace(strings.TrimPrefix(string(data), "\ufeff"))
+	return strings.HasPrefix(trimmed, "{")
+}
+
+func (i jsonFeedItem) id() string {
+	var text string
+	if json.Unmarshal(i.ID, &text) == nil {
+		return text
+	}
+	var number json.Number
+	if json.Unmarshal(i.ID, &number) == nil {
+		return number.String()
+	}
+	return ""
+}
+
+func (i jsonFeedItem) author() string {
+	var names []string
+	for _, author := range i.Authors {
+		if author.Name != "" {
+			names = append(names, author.Name)
+		}
+	}
+	if len(names) == 0 && i.Author != nil && i.Author.Name != "" {
+		names = append(names, i.Author.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func parseJSONFeed(data []byte) (*RSSFeed, error) {
+	var jf jsonFeed
+	err := json.Unmarshal(data, &jf)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing json feed: %v", err)
+	}
+	if !strings.HasPrefix(jf.Version, "https://jsonfeed.org/version/") {
+		return nil, fmt.Errorf("unsupported json feed version: %q", jf.Version)
+	}
+	var feed RSSFeed
+	feed.Channel.Title = jf.Title
+	feed.Channel.Link = jf.HomePageURL
+	feed.Channel.Description = jf.Description
+	for _, entry := range jf.Items {
+		item := RSSItem{
+			Title: entry.Title,
+			Link: entry.URL,
+			Description: entry.ContentHTML,
+			PubDate: entry.DatePublished,
+			GUID: entry.id(),
+			Author: entry.author(),
+			Categories: entry.Tags,
+		}
+		if item.Link == "" {
+			item.Link = entry.ExternalURL
+		}
+		if item.Description == "" {
+			item.Description = entry.ContentText
+		}
+		if item.Description == "" {
+			item.Description = entry.Summary
+		}
+		if item.PubDate == "" {
+			item.PubDate = entry.DateModified
+		}
+		for _, attachment := range entry.Attachments {
+			enclosure := RSSEnclosure{
+				URL: attachment.URL,
+				Type: attachment.MimeType,
+			}
+			if attachment.SizeInBytes > 0 {
+				enclosure.Length = strconv.FormatInt(attachment.SizeInBytes, 10)
+			}
+			if attachment.DurationInSeconds > 0 {
+				enclosure.Duration = strconv.FormatFloat(attachment.DurationInSeconds, 'f', 0, 64)
+			}
+			item.Enclosures = append(item.Enclosures, enclosure)
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+	return &feed, nil
+}
@@ -15,8 +15,18 @@ import (
 	"github.com/Lynn-Xy/bloggatog/internal/database"
 	"log"
 	"time"
-	"encoding/xml"
 	"strconv"
+	"strings"
+	"sync"
+	"flag"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	feedFetchTimeout = 30 * time.Second
+	feedMaxBytes = 10 << 20
+	feedMaxArticles = 10
 )
 
 type state struct {
@@ -33,11 +43,73 @@ type commands struct {
 	list map[string]func(*state, command) error
 }
 
+type notFoundError struct {
+	message string
+}
+
+func (e *notFoundError) Error() string {
+	return e.message
+}
+
+type ambiguousError struct {
+	message string
+}
+
+func (e *ambiguousError) Error() string {
+	return e.message
+}
+
 type RSSItem struct {
 	Title string `xml:"title"`
 	Link string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate string `xml:"pubDate"`
+	GUID string `xml:"guid"`
+	Author string `xml:"author"`
+	Enclosures []RSSEnclosure `xml:"enclosure"`
+	Categories []string `xml:"category"`
+	DCDate string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	DCCreator string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	MediaContents []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaGroups []mediaGroup `xml:"http://search.yahoo.com/mrss/ group"`
+	ITunesDuration string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ITunesSummary string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+	ContentHash string `xml:"-"`
+}
+
+type feedResponse struct {
+	Feed *RSSFeed
+	StatusCode int
+	NotModified bool
+	ETag string
+	LastModified string
+	MaxAge time.Duration
+	RetryAfter time.Time
+}
+
+type aggOptions struct {
+	Workers int
+	BatchSize int
+	DisableAfter int
+	Timeout time.Duration
+	Policy pollPolicy
+}
+
+type browseOptions struct {
+	UnreadOnly bool
+	Folder string
+	FeedID uuid.NullUUID
+	Query string
+	EditedOnly bool
+	Limit int32
+	Offset int32
+}
+
+type RSSEnclosure struct {
+	URL string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type string `xml:"type,attr"`
+	Duration string `xml:"-"`
 }
 
 type RSSFeed struct {
@@ -45,6 +117,9 @@ type RSSFeed struct {
 		Title string `xml:"title"`
 		Link string `xml:"link"`
 		Description string `xml:"description"`
+		TTL string `xml:"ttl"`
+		SkipHours []string `xml:"skipHours>hour"`
+		SkipDays []string `xml:"skipDays>day"`
 		Item []RSSItem `xml:"item"`
 	} `xml:"channel"`
 }
@@ -61,6 +136,22 @@ func (c *commands) Run(s *state, cmd command) error {
 	return nil
 }
 
+func parseArguments(flags *flag.FlagSet, arguments []string) ([]string, error) {
+	var positional []string
+	for {
+		err := flags.Parse(arguments)
+		if err != nil {
+			return nil, err
+		}
+		arguments = flags.Args()
+		if len(arguments) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, arguments[0])
+		arguments = arguments[1:]
+	}
+}
+
 func (c *commands) Register(name string, f func(*state, command) error) {
 	c.list[name] = f
 }
@@ -89,7 +180,12 @@ func HandlerRegister(s *state, cmd command) error {
 	if err == nil {
 		return fmt.Errorf("error registering new user: %v - user already exists in database", cmd.Arguments[0])
 	}
-	params := database.CreateUserParams{uuid.New(), time.Now(), time.Now(), cmd.Arguments[0]}
+	params := database.CreateUserParams{
+		ID: uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name: cmd.Arguments[0],
+	}
 	_, err = s.db.CreateUser(context.Background(), params)
 	if err != nil {
 		return fmt.Errorf("error creating new user: %v", err)
@@ -103,31 +199,33 @@ func HandlerRegister(s *state, cmd command) error {
 }
 
 func HandlerAddFeed(s *state, cmd command, user database.User) error {
-	if len(cmd.Arguments) > 2 {
+	flags := flag.NewFlagSet("addfeed", flag.ContinueOnError)
+	auto := flags.Bool("auto", false, "pick the best discovered feed without prompting")
+	direct := flags.Bool("no-discover", false, "add the url as the feed without fetching it first")
+	args, err := parseArguments(flags, cmd.Arguments)
+	if err != nil {
+		return fmt.Errorf("error parsing addfeed flags: %v", err)
+	}
+	if len(args) > 2 {
 		return errors.New("Feed name must be only one word, camelcase combinations are permitted")
 	}
-	feedParams := database.CreateFeedParams{
-		ID: uuid.New(),
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		Name: sql.NullString{
-			String: cmd.Arguments[0],
-			Valid: true,
-		},
-		Url: cmd.Arguments[1],
-		UserID: user.ID,
+	if len(args) < 2 {
+		return errors.New("feed name and url must be provided")
 	}
-	feed, err := s.db.CreateFeed(context.Background(), feedParams)
-	if err != nil {
-		return fmt.Errorf("error creating feed in database: %v", err)
+	ctx := context.Background()
+	feedURL := args[1]
+	if !*direct {
+		feedURL, err = discoverFeedURL(ctx, args[1], !*auto)
+		if err != nil {
+			return fmt.Errorf("error discovering feed (use --no-discover to add the url as is): %v", err)
+		}
 	}
-	feedFollowParams := database.CreateFeedFollowParams{
-		UserID: user.ID,
-		FeedID: feed.ID,
+	if feedURL != args[1] {
+		fmt.Printf("discovered feed: %v\n", feedURL)
 	}
-	_, err = s.db.CreateFeedFollow(context.Background(), feedFollowParams)
+	feed, err := addFeed(ctx, s, user, args[0], feedURL)
 	if err != nil {
-		return fmt.Errorf("error creating feed_follow in database: %v", err)
+		return err
 	}
 	fmt.Printf(`
 	* Id: %v
@@ -145,6 +243,33 @@ func HandlerAddFeed(s *state, cmd command, user database.User) error {
 	return nil
 }
 
+func addFeed(ctx context.Context, s *state, user database.User, name string, url string) (database.Feed, error) {
+	feedParams := database.CreateFeedParams{
+		ID: uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Name: sql.NullString{
+			String: name,
+			Valid: true,
+		},
+		Url: url,
+		UserID: user.ID,
+	}
+	feed, err := s.db.CreateFeed(ctx, feedParams)
+	if err != nil {
+		return database.Feed{}, fmt.Errorf("error creating feed in database: %w", err)
+	}
+	feedFollowParams := database.CreateFeedFollowParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+	}
+	_, err = s.db.CreateFeedFollow(ctx, feedFollowParams)
+	if err != nil {
+		return database.Feed{}, fmt.Errorf("error creating feed_follow in database: %w", err)
+	}
+	return feed, nil
+}
+
 func HandlerUsers(s *state, cmd command) error {
 	users, err := s.db.GetAllUsers(context.Background())
 	if err != nil {
@@ -170,22 +295,51 @@ func HandlerReset(s *state, cmd command) error {
 }
 
 func HandlerAgg(s *state, cmd command) error {
-	if len(cmd.Arguments) < 1 {
+	flags := flag.NewFlagSet("agg", flag.ContinueOnError)
+	workers := flags.Int("workers", 4, "number of feeds fetched concurrently")
+	batchSize := flags.Int("batch", 20, "number of due feeds claimed at a time")
+	minInterval := flags.Duration("min-interval", 10*time.Minute, "shortest time between fetches of a feed")
+	maxInterval := flags.Duration("max-interval", 24*time.Hour, "longest time between fetches of a feed")
+	disableAfter := flags.Int("disable-after", 10, "consecutive failures before a feed is disabled, 0 to never disable")
+	timeout := flags.Duration("timeout", feedFetchTimeout, "longest time fetching a feed and its full articles may take")
+	args, err := parseArguments(flags, cmd.Arguments)
+	if err != nil {
+		return fmt.Errorf("error parsing agg flags: %v", err)
+	}
+	if len(args) < 1 {
 		return errors.New("time string must be provided")
 	}
-	time_between_reqs, err := time.ParseDuration(cmd.Arguments[0])
+	if *workers < 1 || *batchSize < 1 {
+		return errors.New("workers and batch must be at least 1")
+	}
+	if *minInterval <= 0 || *maxInterval < *minInterval {
+		return errors.New("min-interval must be positive and no greater than max-interval")
+	}
+	if *timeout <= 0 || *timeout >= *minInterval {
+		return errors.New("timeout must be positive and shorter than min-interval")
+	}
+	options := aggOptions{
+		Workers: *workers,
+		BatchSize: *batchSize,
+		DisableAfter: *disableAfter,
+		Timeout: *timeout,
+		Policy: pollPolicy{
+			MinInterval: *minInterval,
+			MaxInterval: *maxInterval,
+		},
+	}
+	time_between_reqs, err := time.ParseDuration(args[0])
 	if err != nil {
 		return fmt.Errorf("error parsing time string argument: %v", err)
 	}
-	fmt.Printf("Collecting feeds every %v", time_between_reqs)
+	fmt.Printf("Checking for due feeds every %v with %v workers\n", time_between_reqs, options.Workers)
 	tick := time.NewTicker(time_between_reqs)
 	for ; ; <-tick.C {
-		err := scrapeFeeds(s)
+		err := scrapeFeeds(s, options)
 		if err != nil {
 			log.Printf("error scraping feeds: %v", err)
 		}
 	}
-	return nil
 }
 
 func HandlerFeeds(s *state, cmd command) error {
@@ -205,19 +359,95 @@ func HandlerFeeds(s *state, cmd command) error {
 	return nil
 }
 
-func HandlerFollow(s *state, cmd command, user database.User) error {
-	url := cmd.Arguments[0]
-	feed, err := s.db.GetFeedByUrl(context.Background(), url)
+func HandlerFeedStatus(s *state, cmd command) error {
+	feeds, err := s.db.GetUnhealthyFeeds(context.Background())
 	if err != nil {
-		return fmt.Errorf("error retrieving feed from database: %v", err)
+		return fmt.Errorf("error retrieving unhealthy feeds from database: %v", err)
+	}
+	if len(feeds) == 0 {
+		fmt.Println("all feeds are healthy")
+		return nil
+	}
+	for _, feed := range feeds {
+		fmt.Printf("* Name: %v\n", feed.Name.String)
+		fmt.Printf("  Url: %v\n", feed.Url)
+		fmt.Printf("  Consecutive Failures: %v\n", feed.ConsecutiveFailures)
+		if feed.LastStatus.Valid {
+			fmt.Printf("  Last Status: %v\n", feed.LastStatus.Int32)
+		}
+		if feed.LastError.Valid {
+			fmt.Printf("  Last Error: %v\n", feed.LastError.String)
+		}
+		if feed.DisabledAt.Valid {
+			fmt.Printf("  Disabled Since: %v\n", feed.DisabledAt.Time)
+		} else if feed.NextFetchAt.Valid {
+			fmt.Printf("  Next Retry: %v\n", feed.NextFetchAt.Time)
+		}
+	}
+	return nil
+}
+
+func userFeedByUrl(ctx context.Context, s *state, user database.User, url string) (database.GetUserFeedByUrlRow, error) {
+	feed, err := s.db.GetUserFeedByUrl(ctx, database.GetUserFeedByUrlParams{
+		Url: url,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.GetUserFeedByUrlRow{}, &notFoundError{fmt.Sprintf("%v is not a feed you follow or added", url)}
+	}
+	if err != nil {
+		return database.GetUserFeedByUrlRow{}, fmt.Errorf("error retrieving feed from database: %v", err)
+	}
+	return feed, nil
+}
+
+func HandlerEnableFeed(s *state, cmd command, user database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return errors.New("feed url must be provided")
+	}
+	feed, err := userFeedByUrl(context.Background(), s, user, cmd.Arguments[0])
+	if err != nil {
+		return err
+	}
+	params := database.EnableFeedByIDParams{
+		UpdatedAt: time.Now().UTC(),
+		ID: feed.ID,
+	}
+	err = s.db.EnableFeedByID(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("error enabling feed: %v", err)
+	}
+	fmt.Printf("feed %v re-enabled and scheduled for the next agg run\n", feed.Url)
+	return nil
+}
+
+func followFeed(ctx context.Context, s *state, user database.User, url string) (database.CreateFeedFollowRow, error) {
+	feed, err := s.db.GetFeedByUrl(ctx, url)
+	if errors.Is(err, sql.ErrNoRows) {
+		discovered, discoverErr := findDiscoveredFeed(ctx, s, url)
+		if discoverErr != nil {
+			return database.CreateFeedFollowRow{}, &notFoundError{fmt.Sprintf("no feed found for %v: %v", url, discoverErr)}
+		}
+		feed, err = s.db.GetFeedByUrl(ctx, discovered)
+	}
+	if err != nil {
+		return database.CreateFeedFollowRow{}, fmt.Errorf("error retrieving feed from database: %w", err)
 	}
 	feedFollowParams := database.CreateFeedFollowParams{
 		UserID: user.ID,
 		FeedID: feed.ID,
 	}
-	feed_follow_row, err := s.db.CreateFeedFollow(context.Background(), feedFollowParams)
+	feed_follow_row, err := s.db.CreateFeedFollow(ctx, feedFollowParams)
+	if err != nil {
+		return database.CreateFeedFollowRow{}, fmt.Errorf("error creating feed_follow_row: %w", err)
+	}
+	return feed_follow_row, nil
+}
+
+func HandlerFollow(s *state, cmd command, user database.User) error {
+	feed_follow_row, err := followFeed(context.Background(), s, user, cmd.Arguments[0])
 	if err != nil {
-		return fmt.Errorf("error creating feed_follow_row: %v", err)
+		return err
 	}
 	fmt.Printf("* Feed Name: %v", feed_follow_row.FeedName.String)
 	fmt.Printf("* User Name: %v", feed_follow_row.UserName)
@@ -225,54 +455,139 @@ func HandlerFollow(s *state, cmd command, user database.User) error {
 }
 
 func HandlerFollowing(s *state, cmd command, user database.User) error {
-	feed_follows, err := s.db.GetFeedFollowForUser(context.Background(), user.ID)
+	feeds, err := s.db.GetFollowedFeedsByUserID(context.Background(), user.ID)
 	if err != nil {
 		return fmt.Errorf("error retrieving feed_follows from database: %v", err)
 	}
-	for _, follow := range feed_follows {
-		feedName, err := s.db.GetFeedNameByFeedID(context.Background(), follow.FeedID)
-		if err != nil {
-			return fmt.Errorf("error retrieving feed name from database: %v", err)
+	folder := ""
+	for _, feed := range feeds {
+		if feed.FolderName.String != folder {
+			folder = feed.FolderName.String
+			fmt.Printf("%v/\n", folder)
 		}
-		fmt.Printf("* Feed Name: %v\n", feedName.String)
+		if folder != "" {
+			fmt.Print("  ")
+		}
+		fmt.Printf("* Feed Name: %v\n", feed.Name.String)
 	}
 	return nil
 }
 
-func HandlerUnfollow(s *state, cmd command, user database.User) error {
-	url := cmd.Arguments[0]
-	feed, err := s.db.GetFeedByUrl(context.Background(), url)
+func unfollowFeed(ctx context.Context, s *state, user database.User, url string) error {
+	feed, err := s.db.GetFeedByUrl(ctx, url)
 	if err != nil {
-		return fmt.Errorf("error retrieving feed from database: %v", err)
+		return fmt.Errorf("error retrieving feed from database: %w", err)
 	}
 	params := database.UnfollowFeedByIDParams{
 		UserID: user.ID,
 		FeedID: feed.ID,
 	}
-	err = s.db.UnfollowFeedByID(context.Background(), params)
+	err = s.db.UnfollowFeedByID(ctx, params)
 	if err != nil {
 		return fmt.Errorf("error unfollowing feed: %v", err)
 	}
 	return nil
 }
 
+func HandlerUnfollow(s *state, cmd command, user database.User) error {
+	return unfollowFeed(context.Background(), s, user, cmd.Arguments[0])
+}
+
+func browsePosts(ctx context.Context, s *state, user database.User, options browseOptions) ([]database.GetXPostsByUserIDRow, error) {
+	params :=  database.GetXPostsByUserIDParams{
+		ID: user.ID,
+		UnreadOnly: options.UnreadOnly,
+		FeedID: options.FeedID,
+		Query: sql.NullString{
+			String: options.Query,
+			Valid: options.Query != "",
+		},
+		EditedOnly: options.EditedOnly,
+		PostLimit: options.Limit,
+		PostOffset: options.Offset,
+	}
+	if options.Folder != "" {
+		folder, err := folderByName(ctx, s, user, options.Folder)
+		if err != nil {
+			return nil, err
+		}
+		params.FolderID = uuid.NullUUID{
+			UUID: folder.ID,
+			Valid: true,
+		}
+	}
+	posts, err := s.db.GetXPostsByUserID(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving posts from database: %v", err)
+	}
+	return posts, nil
+}
+
 func HandlerBrowse(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet("browser", flag.ContinueOnError)
+	unread := flags.Bool("unread", false, "only show posts that have not been read")
+	folderName := flags.String("folder", "", "only show posts from feeds in this folder")
+	query := flags.String("query", "", "only show posts matching this search query")
+	interactive := flags.Bool("interactive", false, "open a full-screen reader instead of printing posts")
+	full := flags.Bool("full", false, "print the whole article instead of an excerpt")
+	edited := flags.Bool("updated", false, "only show posts that were edited after they were published")
+	args, err := parseArguments(flags, cmd.Arguments)
+	if err != nil {
+		return fmt.Errorf("error parsing browser flags: %v", err)
+	}
+	if *interactive {
+		return runTUI(s, user, browseOptions{
+			UnreadOnly: *unread,
+			Folder: *folderName,
+			Query: *query,
+			EditedOnly: *edited,
+		})
+	}
 	var limit int32
-	if len(cmd.Arguments) < 1 {
+	if len(args) < 1 {
 		limit = 2
 	} else {
-		limit = int32(strconv.Atoi(cmd.Arguments[0])
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("error parsing limit argument: %v", err)
+		}
+		limit = int32(n)
 	}
-	params :=  database.GetXPostsByUserIDParams{
-		ID: user.ID,
+	posts, err := browsePosts(context.Background(), s, user, browseOptions{
+		UnreadOnly: *unread,
+		Folder: *folderName,
+		Query: *query,
+		EditedOnly: *edited,
 		Limit: limit,
-	}
-	posts, err := s.db.GetXPostsByUserID(context.Background(), params)
+	})
 	if err != nil {
-		return fmt.Errorf("error retrieving posts from database: %v", err)
+		return err
 	}
 	for _, post := range posts {
-		fmt.Printf("* Post: %+v\n", post)
+		date := post.CreatedAt
+		if post.PublishedAt.Valid {
+			date = post.PublishedAt.Time
+		}
+		meta := []string{post.FeedName.String, date.Format("2006-01-02 15:04")}
+		if !post.ReadAt.Valid && !post.Muted {
+			meta = append(meta, "unread")
+		}
+		if post.StarredAt.Valid {
+			meta = append(meta, "starred")
+		}
+		if post.EditedAt.Valid {
+			meta = append(meta, "updated "+post.EditedAt.Time.Format("2006-01-02 15:04"))
+		}
+		fmt.Printf("* %v\n", post.Title.String)
+		fmt.Printf("  %v\n", strings.Join(meta, " | "))
+		fmt.Printf("  %v\n", post.Url)
+		if *full {
+			for _, line := range renderText(postBody(post), 76) {
+				fmt.Printf("  %v\n", line)
+			}
+		} else if text := excerpt(post.Description.String); text != "" {
+			fmt.Printf("  %v\n", text)
+		}
 	}
 	return nil
 }
@@ -287,77 +602,238 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
 	}
 }
 
-func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+func fetchFeed(ctx context.Context, feedURL string, etag string, lastModified string) (*feedResponse, error) {
 	newClient := &http.Client{}
 	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating http request: %v", err)
 	}
 	req.Header.Set("User-Agent", "gator")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/feed+json, application/xml;q=0.9, */*;q=0.8")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
 	resp, err := newClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error sending http get request: %v", err)
 	}
 	defer resp.Body.Close()
+	response := &feedResponse{
+		StatusCode: resp.StatusCode,
+		ETag: resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		MaxAge: parseMaxAge(resp.Header.Get("Cache-Control")),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now().UTC()),
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		response.NotModified = true
+		if response.ETag == "" {
+			response.ETag = etag
+		}
+		if response.LastModified == "" {
+			response.LastModified = lastModified
+		}
+		return response, nil
+	}
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("error retrieving rss feed contents: server response %v", resp.StatusCode)
+		return response, fmt.Errorf("error retrieving rss feed contents: server response %v", resp.StatusCode)
 	}
-	data, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(io.LimitReader(resp.Body, feedMaxBytes+1))
 	if err != nil {
-		return nil, fmt.Errorf("error reading http get response body: %v", err)
+		return response, fmt.Errorf("error reading http get response body: %v", err)
+	}
+	if len(data) > feedMaxBytes {
+		return response, fmt.Errorf("error reading http get response body: feed is larger than %v bytes", feedMaxBytes)
 	}
-	var feed RSSFeed
-	err = xml.Unmarshal(data, &feed)
+	feed, err := parseFeed(data, resp.Header.Get("Content-Type"))
 	if err != nil {
-		return nil, fmt.Errorf("error unmarshaling http get request response body data: %v", err)
+		return response, fmt.Errorf("error unmarshaling http get request response body data: %v", err)
 	}
 	for idx, item := range feed.Channel.Item {
+		feed.Channel.Item[idx].ContentHash = itemContentHash(item)
 		cleanedTitle := html.UnescapeString(item.Title)
 		feed.Channel.Item[idx].Title = cleanedTitle
-		cleanedDescription := html.UnescapeString(item.Description)
+		base := resp.Request.URL
+		if link, err := base.Parse(item.Link); err == nil && item.Link != "" {
+			base = link
+		}
+		cleanedDescription := sanitizeHTML(item.Description, base)
 		feed.Channel.Item[idx].Description = cleanedDescription
 	}
 	cleanedTitle := html.UnescapeString(feed.Channel.Title)
 	feed.Channel.Title = cleanedTitle
 	cleanedDescription := html.UnescapeString(feed.Channel.Description)
 	feed.Channel.Description = cleanedDescription
-	return &feed, nil
+	response.Feed = feed
+	return response, nil
 }
 
-func scrapeFeeds(s *state) error {
-	username := s.cfg.CurrentUsername
-	if username == "guest" || username == "" {
-		return errors.New("no user currently logged in")
-	}
+type scrapeResult struct {
+	Url string
+	NewPosts int
+	UpdatedPosts int
+	NotModified bool
+	Disabled bool
+	Err error
+}
+
+func scrapeFeeds(s *state, options aggOptions) error {
 	ctx := context.Background()
-	user, err := s.db.GetUserByName(ctx, username)
-	if err != nil { return fmt.Errorf("error retrieving user from database: %v", err)
+	start := time.Now().UTC()
+	var results []scrapeResult
+	for {
+		now := time.Now().UTC()
+		params := database.ClaimDueFeedsParams{
+			FetchedAt: sql.NullTime{
+				Time: now,
+				Valid: true,
+			},
+			LeaseUntil: sql.NullTime{
+				Time: now.Add(options.Policy.MinInterval),
+				Valid: true,
+			},
+			BatchSize: int32(options.BatchSize),
+		}
+		feedRows, err := s.db.ClaimDueFeeds(ctx, params)
+		if err != nil {
+			return fmt.Errorf("error claiming feeds to fetch: %v", err)
+		}
+		if len(feedRows) == 0 {
+			break
+		}
+		results = append(results, scrapeBatch(ctx, s, feedRows, options)...)
 	}
-	feedRow, err := s.db.GetNextFeedToFetchByUserID(ctx, user.ID)
-	if err != nil {
-		return fmt.Errorf("error retrieving next feed from database: %v", err)
+	var newPosts, updatedPosts, notModified, failed, disabled int
+	for _, result := range results {
+		newPosts += result.NewPosts
+		updatedPosts += result.UpdatedPosts
+		if result.NotModified {
+			notModified++
+		}
+		if result.Disabled {
+			disabled++
+		}
+		if result.Err != nil {
+			failed++
+			log.Printf("error scraping feed %v: %v", result.Url, result.Err)
+		}
 	}
-	params := MarkFeedFetchedByIDParams{
-		LastFetchedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		ID: feedRow.ID,
+	log.Printf("fetched %v feeds in %v: %v new posts, %v updated posts, %v not modified, %v failed, %v disabled",
+		len(results),
+		time.Since(start).Round(time.Millisecond),
+		newPosts,
+		updatedPosts,
+		notModified,
+		failed,
+		disabled)
+	return nil
+}
+
+func scrapeBatch(ctx context.Context, s *state, feedRows []database.ClaimDueFeedsRow, options aggOptions) []scrapeResult {
+	jobs := make(chan database.ClaimDueFeedsRow)
+	results := make(chan scrapeResult, len(feedRows))
+	var wg sync.WaitGroup
+	for i := 0; i < options.Workers && i < len(feedRows); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for feedRow := range jobs {
+				results <- scrapeFeed(ctx, s, feedRow, options)
+			}
+		}()
 	}
-	err = s.db.MarkFeedFetchedByID(ctx, params)
-	if err != nil {
-		return fmt.Errorf("error marking feed fetched: %v", err)
+	for _, feedRow := range feedRows {
+		jobs <- feedRow
+	}
+	close(jobs)
+	wg.Wait()
+	close(results)
+	var collected []scrapeResult
+	for result := range results {
+		collected = append(collected, result)
 	}
+	return collected
+}
+
+func scrapeFeed(ctx context.Context, s *state, feedRow database.ClaimDueFeedsRow, options aggOptions) scrapeResult {
 	url := feedRow.Url
-	RSSfeed, err := fetchFeed(ctx, url)
+	result := scrapeResult{Url: url}
+	fetchCtx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+	response, err := fetchFeed(fetchCtx, url, feedRow.Etag.String, feedRow.LastModified.String)
 	if err != nil {
-		return fmt.Errorf("error fetching feed from url: %v - %v", url, err)
-	}
-	for _, item := range RSSfeed.Channel.Item {
-		date, err := time.Parse(DateOnly, item.PubDate)
+		result.Err = fmt.Errorf("error fetching feed from url: %v - %v", url, err)
+		disabled, err := recordFeedFailure(ctx, s, feedRow.ID, options, response, result.Err)
 		if err != nil {
+			log.Printf("error recording feed failure for %v: %v", url, err)
+		}
+		result.Disabled = disabled
+		return result
+	}
+	if response.NotModified {
+		result.NotModified = true
+	} else {
+		result.NewPosts, result.UpdatedPosts, result.Err = storeFeedItems(ctx, fetchCtx, s, feedRow, response)
+	}
+	err = recordFeedSuccess(ctx, s, feedRow.ID, options.Policy, response)
+	if err != nil && result.Err == nil {
+		result.Err = err
+	}
+	return result
+}
+
+func itemGUID(item RSSItem) string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		return guid
+	}
+	key := item.Link
+	if key == "" {
+		key = item.Title + "\n" + item.PubDate + "\n" + item.Description
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func itemContentHash(item RSSItem) string {
+	sum := sha256.Sum256([]byte(item.Title + "\n" + item.Link + "\n" + item.Description))
+	return hex.EncodeToString(sum[:])
+}
+
+// storeFeedItems upserts the items of a fetched feed. Full articles are
+// fetched with fetchCtx, which shares the feed fetch deadline so the
+// feed is done before its claim lease runs out, and at most
+// feedMaxArticles are fetched per run.
+func storeFeedItems(ctx context.Context, fetchCtx context.Context, s *state, feedRow database.ClaimDueFeedsRow, response *feedResponse) (int, int, error) {
+	feedID := feedRow.ID
+	newPosts := 0
+	updatedPosts := 0
+	articles := 0
+	skippedArticles := 0
+	for _, item := range response.Feed.Channel.Item {
+		guid := itemGUID(item)
+		if feedRow.LegacyGuids && item.Link != "" && guid != item.Link {
+			adoptParams := database.AdoptLegacyPostGuidParams{
+				Guid: guid,
+				FeedID: feedID,
+				Url: item.Link,
+			}
+			_, err := s.db.AdoptLegacyPostGuid(ctx, adoptParams)
+			if err != nil {
+				log.Printf("error updating post guid in database: %v", err)
+			}
+		}
+		publishedAt := sql.NullTime{}
+		date, err := parseDate(item.PubDate)
+		if err == nil {
+			publishedAt.Time = clampDate(date, time.Now().UTC())
+			publishedAt.Valid = true
+		} else if item.PubDate != "" {
 			log.Printf("error parsing publication date: %v", err)
-			date = time.Now().UTC()
 		}
-		postParams := CreatePostParams{
+		postParams := database.UpsertPostParams{
 			ID: uuid.New(),
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
@@ -370,18 +846,177 @@ func scrapeFeeds(s *state) error {
 				String: item.Description,
 				Valid: true,
 			},
-			PublishedAt: date,
-			FeedID: feedRow.ID,
+			PublishedAt: publishedAt,
+			FeedID: feedID,
+			Author: sql.NullString{
+				String: item.Author,
+				Valid: item.Author != "",
+			},
+			PublishedRaw: sql.NullString{
+				String: item.PubDate,
+				Valid: item.PubDate != "",
+			},
+			Categories: item.Categories,
+			Guid: guid,
+			ContentHash: sql.NullString{
+				String: item.ContentHash,
+				Valid: item.ContentHash != "",
+			},
 		}
 
-		err = s.db.CreatePost(ctx, postParams)
-		if err != nil && strings.Contains(err.Error(), "unique") == false {
-			log.Printf("error creating post in database: %v", err)
+		post, err := s.db.UpsertPost(ctx, postParams)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("error storing post in database: %v", err)
+			continue
+		}
+		storeAttachments(ctx, s, feedID, guid, item.Enclosures)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if post.Inserted {
+			newPosts++
+		}
+		if post.Edited {
+			updatedPosts++
+			log.Printf("post %v was edited, previous version kept", post.Url)
+		}
+		if feedRow.FetchFullContent && post.Url != "" {
+			if articles >= feedMaxArticles || fetchCtx.Err() != nil {
+				skippedArticles++
+				continue
+			}
+			articles++
+			err := storePostContent(fetchCtx, s, post.ID, post.Url)
+			if err != nil {
+				log.Printf("error fetching full content for %v: %v", post.Url, err)
+			}
+		}
+	}
+	if skippedArticles > 0 {
+		log.Printf("skipped full content for %v posts from %v: article limit or fetch timeout reached", skippedArticles, feedRow.Url)
+	}
+	if feedRow.LegacyGuids {
+		legacyParams := database.ClearFeedLegacyGuidsByIDParams{
+			UpdatedAt: time.Now().UTC(),
+			ID: feedID,
+		}
+		err := s.db.ClearFeedLegacyGuidsByID(ctx, legacyParams)
+		if err != nil {
+			log.Printf("error clearing legacy post guids flag: %v", err)
 		}
 	}
+	if response.Feed.Channel.Link != "" {
+		siteParams := database.UpdateFeedSiteUrlByIDParams{
+			SiteUrl: sql.NullString{
+				String: response.Feed.Channel.Link,
+				Valid: true,
+			},
+			UpdatedAt: time.Now().UTC(),
+			ID: feedID,
+		}
+		err := s.db.UpdateFeedSiteUrlByID(ctx, siteParams)
+		if err != nil {
+			log.Printf("error updating feed site url: %v", err)
+		}
+	}
+	cacheParams := database.UpdateFeedCacheHeadersByIDParams{
+		Etag: sql.NullString{
+			String: response.ETag,
+			Valid: response.ETag != "",
+		},
+		LastModified: sql.NullString{
+			String: response.LastModified,
+			Valid: response.LastModified != "",
+		},
+		UpdatedAt: time.Now().UTC(),
+		ID: feedID,
+	}
+	err := s.db.UpdateFeedCacheHeadersByID(ctx, cacheParams)
+	if err != nil {
+		return newPosts, updatedPosts, fmt.Errorf("error updating feed cache headers: %v", err)
+	}
+	return newPosts, updatedPosts, nil
+}
+
+func recordFeedSuccess(ctx context.Context, s *state, feedID uuid.UUID, policy pollPolicy, response *feedResponse) error {
+	dateParams := database.GetRecentPostDatesByFeedIDParams{
+		FeedID: feedID,
+		Limit: recentPostSample,
+	}
+	postDates, err := s.db.GetRecentPostDatesByFeedID(ctx, dateParams)
+	if err != nil {
+		return fmt.Errorf("error retrieving recent post dates: %v", err)
+	}
+	now := time.Now().UTC()
+	params := database.RecordFeedSuccessByIDParams{
+		LastStatus: sql.NullInt32{
+			Int32: int32(response.StatusCode),
+			Valid: true,
+		},
+		NextFetchAt: sql.NullTime{
+			Time: nextFetchTime(now, policy, response, postDates),
+			Valid: true,
+		},
+		UpdatedAt: now,
+		ID: feedID,
+	}
+	err = s.db.RecordFeedSuccessByID(ctx, params)
+	if err != nil {
+		return fmt.Errorf("error scheduling next fetch: %v", err)
+	}
 	return nil
 }
 
+func recordFeedFailure(ctx context.Context, s *state, feedID uuid.UUID, options aggOptions, response *feedResponse, fetchErr error) (bool, error) {
+	now := time.Now().UTC()
+	params := database.RecordFeedFailureByIDParams{
+		LastError: sql.NullString{
+			String: fetchErr.Error(),
+			Valid: true,
+		},
+		UpdatedAt: now,
+		ID: feedID,
+	}
+	if response != nil {
+		params.LastStatus = sql.NullInt32{
+			Int32: int32(response.StatusCode),
+			Valid: true,
+		}
+	}
+	failures, err := s.db.RecordFeedFailureByID(ctx, params)
+	if err != nil {
+		return false, fmt.Errorf("error recording feed failure: %v", err)
+	}
+	if options.DisableAfter > 0 && int(failures) >= options.DisableAfter {
+		disableParams := database.DisableFeedByIDParams{
+			DisabledAt: sql.NullTime{
+				Time: now,
+				Valid: true,
+			},
+			UpdatedAt: now,
+			ID: feedID,
+		}
+		err = s.db.DisableFeedByID(ctx, disableParams)
+		if err != nil {
+			return false, fmt.Errorf("error disabling feed: %v", err)
+		}
+		return true, nil
+	}
+	nextParams := database.SetFeedNextFetchByIDParams{
+		NextFetchAt: sql.NullTime{
+			Time: backoffFetchTime(now, options.Policy, response, int(failures)),
+			Valid: true,
+		},
+		UpdatedAt: now,
+		ID: feedID,
+	}
+	err = s.db.SetFeedNextFetchByID(ctx, nextParams)
+	if err != nil {
+		return false, fmt.Errorf("error scheduling retry: %v", err)
+	}
+	return false, nil
+}
+
 func main() {
 	config, err := cfg.Read()
 	if err != nil {
@@ -409,6 +1044,26 @@ func main() {
 	commands.Register("following", middlewareLoggedIn(HandlerFollowing))
 	commands.Register("unfollow", middlewareLoggedIn(HandlerUnfollow))
 	commands.Register("browser", middlewareLoggedIn(HandlerBrowse))
+	commands.Register("feedstatus", HandlerFeedStatus)
+	commands.Register("enablefeed", middlewareLoggedIn(HandlerEnableFeed))
+	commands.Register("fullcontent", middlewareLoggedIn(HandlerFullContent))
+	commands.Register("import", middlewareLoggedIn(HandlerImport))
+	commands.Register("export", middlewareLoggedIn(HandlerExport))
+	commands.Register("read", middlewareLoggedIn(HandlerRead))
+	commands.Register("unread", middlewareLoggedIn(HandlerUnread))
+	commands.Register("star", middlewareLoggedIn(HandlerStar))
+	commands.Register("unstar", middlewareLoggedIn(HandlerUnstar))
+	commands.Register("starred", middlewareLoggedIn(HandlerStarred))
+	commands.Register("search", middlewareLoggedIn(HandlerSearch))
+	commands.Register("folder", middlewareLoggedIn(HandlerFolder))
+	commands.Register("filter", middlewareLoggedIn(HandlerFilter))
+	commands.Register("apikey", middlewareLoggedIn(HandlerApiKey))
+	commands.Register("serve", HandlerServe)
+	commands.Register("publish", middlewareLoggedIn(HandlerPublish))
+	commands.Register("rivertoken", middlewareLoggedIn(HandlerRiverToken))
+	commands.Register("episodes", middlewareLoggedIn(HandlerEpisodes))
+	commands.Register("download", middlewareLoggedIn(HandlerDownload))
+	commands.Register("diff", middlewareLoggedIn(HandlerDiff))
 
 	args := os.Args
 	if len(args) < 2 {
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Lynn-Xy/bloggatog/internal/database"
+	"github.com/google/uuid"
+)
+
+type opmlSubscription struct {
+	Title string
+	XMLURL string
+	HTMLURL string
+	Folder string
+}
+
+type opmlOutline struct {
+	Text string `xml:"text,attr"`
+	Title string `xml:"title,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	XMLURL string `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL string `xml:"htmlUrl,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string `xml:"version,attr"`
+	Head struct {
+		Title string `xml:"title"`
+		DateCreated string `xml:"dateCreated,omitempty"`
+	} `xml:"head"`
+	Body struct {
+		Outlines []opmlOutline `xml:"outline"`
+	} `xml:"body"`
+}
+
+func parseOPML(r io.Reader) ([]opmlSubscription, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	var subscriptions []opmlSubscription
+	var folders []string
+	var stack []bool
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading opml: %v", err)
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			if !strings.EqualFold(element.Name.Local, "outline") {
+				continue
+			}
+			attrs := make(map[string]string)
+			for _, attr := range element.Attr {
+				attrs[strings.ToLower(attr.Name.Local)] = strings.TrimSpace(attr.Value)
+			}
+			title := attrs["title"]
+			if title == "" {
+				title = attrs["text"]
+			}
+			if attrs["xmlurl"] == "" {
+				folders = append(folders, title)
+				stack = append(stack, true)
+				continue
+			}
+			subscriptions = append(subscriptions, opmlSubscription{
+				Title: title,
+				XMLURL: attrs["xmlurl"],
+				HTMLURL: attrs["htmlurl"],
+				Folder: strings.Join(folders, "/"),
+			})
+			stack = append(stack, false)
+		case xml.EndElement:
+			if !strings.EqualFold(element.Name.Local, "outline") || len(stack) == 0 {
+				continue
+			}
+			if stack[len(stack)-1] {
+				folders = folders[:len(folders)-1]
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return subscriptions, nil
+}
+
+func writeOPML(w io.Writer, title string, subscriptions []opmlSubscription) error {
+	doc := opmlDocument{Version: "2.0"}
+	doc.Head.Title = title
+	doc.Head.DateCreated = time.Now().UTC().Format(time.RFC1123Z)
+	for _, sub := range subscriptions {
+		outline := opmlOutline{
+			Text: sub.Title,
+			Title: sub.Title,
+			Type: "rss",
+			XMLURL: sub.XMLURL,
+			HTMLURL: sub.HTMLURL,
+		}
+		var path []string
+		if sub.Folder != "" {
+			path = strings.Split(sub.Folder, "/")
+		}
+		doc.Body.Outlines = insertOutline(doc.Body.Outlines, path, outline)
+	}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling opml: %v", err)
+	}
+	_, err = io.WriteString(w, xml.Header+string(data)+"\n")
+	if err != nil {
+		return fmt.Errorf("error writing opml: %v", err)
+	}
+	return nil
+}
+
+func insertOutline(outlines []opmlOutline, path []string, outline opmlOutline) []opmlOutline {
+	if len(path) == 0 {
+		return append(outlines, outline)
+	}
+	for idx, existing := range outlines {
+		if existing.XMLURL == "" && existing.Text == path[0] {
+			outlines[idx].Outlines = insertOutline(existing.Outlines, path[1:], outline)
+			return outlines
+		}
+	}
+	folder := opmlOutline{
+		Text: path[0],
+		Title: path[0],
+	}
+	folder.Outlines = insertOutline(nil, path[1:], outline)
+	return append(outlines, folder)
+}
+
+func HandlerImport(s *state, cmd command, user database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return errors.New("opml file path must be provided")
+	}
+	file, err := os.Open(cmd.Arguments[0])
+	if err != nil {
+		return fmt.Errorf("error opening opml file: %v", err)
+	}
+	defer file.Close()
+	subscriptions, err := parseOPML(file)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	var createdFeeds, createdFollows int
+	for _, sub := range subscriptions {
+		feedID, created, err := findOrCreateFeed(ctx, s, user, sub)
+		if err != nil {
+			log.Printf("error importing feed %v: %v", sub.XMLURL, err)
+			continue
+		}
+		if created {
+			createdFeeds++
+		}
+		folderID, err := getOrCreateFolder(ctx, s, user, sub.Folder)
+		if err != nil {
+			log.Printf("error importing folder %v: %v", sub.Folder, err)
+		}
+		params := database.CreateFeedFollowIfMissingParams{
+			UserID: user.ID,
+			FeedID: feedID,
+			FolderID: folderID,
+		}
+		rows, err := s.db.CreateFeedFollowIfMissing(ctx, params)
+		if err != nil {
+			log.Printf("error following feed %v: %v", sub.XMLURL, err)
+			continue
+		}
+		createdFollows += int(rows)
+	}
+	fmt.Printf("imported %v subscriptions: %v new feeds, %v new follows\n", len(subscriptions), createdFeeds, createdFollows)
+	return nil
+}
+
+func findOrCreateFeed(ctx context.Context, s *state, user database.User, sub opmlSubscription) (uuid.UUID, bool, error) {
+	feed, err := s.db.GetFeedByUrl(ctx, sub.XMLURL)
+	if err == nil {
+		return feed.ID, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, false, fmt.Errorf("error retrieving feed from database: %v", err)
+	}
+	name := sub.Title
+	if name == "" {
+		name = sub.XMLURL
+	}
+	params := database.CreateFeedParams{
+		ID: uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Name: sql.NullString{
+			String: name,
+			Valid: true,
+		},
+		Url: sub.XMLURL,
+		UserID: user.ID,
+		SiteUrl: sql.NullString{
+			String: sub.HTMLURL,
+			Valid: sub.HTMLURL != "",
+		},
+	}
+	created, err := s.db.CreateFeed(ctx, params)
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("error creating feed in database: %v", err)
+	}
+	return created.ID, true, nil
+}
+
+func HandlerExport(s *state, cmd command, user database.User) error {
+	rows, err := s.db.GetFollowedFeedsByUserID(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving followed feeds from database: %v", err)
+	}
+	subscriptions := make([]opmlSubscription, 0, len(rows))
+	for _, row := range rows {
+		title := row.Name.String
+		if title == "" {
+			title = row.Url
+		}
+		subscriptions = append(subscriptions, opmlSubscription{
+			Title: title,
+			XMLURL: row.Url,
+			HTMLURL: row.SiteUrl.String,
+			Folder: row.FolderName.String,
+		})
+	}
+	var out io.Writer = os.Stdout
+	if len(cmd.Arguments) > 0 {
+		file, err := os.Create(cmd.Arguments[0])
+		if err != nil {
+			return fmt.Errorf("error creating export file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	err = writeOPML(out, fmt.Sprintf("%v's gator subscriptions", user.Name), subscriptions)
+	if err != nil {
+		return err
+	}
+	if len(cmd.Arguments) > 0 {
+		fmt.Printf("exported %v subscriptions to %v\n", len(subscriptions), cmd.Arguments[0])
+	}
+	return nil
+}
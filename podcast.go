@@ -0,0 +1,502 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Lynn-Xy/bloggatog/internal/database"
+	"github.com/google/uuid"
+)
+
+type mediaContent struct {
+	URL string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Duration string `xml:"duration,attr"`
+	Medium string `xml:"medium,attr"`
+}
+
+type mediaGroup struct {
+	Contents []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+}
+
+type episodeDownload struct {
+	Title string
+	Path string
+	Bytes int64
+	Skipped bool
+	Err error
+}
+
+func isEpisodeMedia(content mediaContent) bool {
+	if content.Medium == "audio" || content.Medium == "video" {
+		return true
+	}
+	return strings.HasPrefix(content.Type, "audio/") || strings.HasPrefix(content.Type, "video/")
+}
+
+func podcastEnclosures(item RSSItem) []RSSEnclosure {
+	seen := make(map[string]bool)
+	var enclosures []RSSEnclosure
+	for _, enclosure := range item.Enclosures {
+		if enclosure.URL != "" && !seen[enclosure.URL] {
+			seen[enclosure.URL] = true
+			enclosures = append(enclosures, enclosure)
+		}
+	}
+	contents := item.MediaContents
+	for _, group := range item.MediaGroups {
+		contents = append(contents, group.Contents...)
+	}
+	for _, content := range contents {
+		if content.URL == "" || seen[content.URL] || !isEpisodeMedia(content) {
+			continue
+		}
+		seen[content.URL] = true
+		enclosures = append(enclosures, RSSEnclosure{
+			URL: content.URL,
+			Length: content.FileSize,
+			Type: content.Type,
+			Duration: content.Duration,
+		})
+	}
+	for idx := range enclosures {
+		if enclosures[idx].Duration == "" {
+			enclosures[idx].Duration = item.ITunesDuration
+		}
+	}
+	return enclosures
+}
+
+func parseEpisodeDuration(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, errors.New("no duration")
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid duration: %v", value)
+	}
+	total := 0.0
+	for _, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration: %v", value)
+		}
+		total = total*60 + n
+	}
+	return int(total + 0.5), nil
+}
+
+func formatEpisodeDuration(seconds int32) string {
+	hours := seconds / 3600
+	minutes := seconds % 3600 / 60
+	if hours > 0 {
+		return fmt.Sprintf("%v:%02d:%02d", hours, minutes, seconds%60)
+	}
+	return fmt.Sprintf("%v:%02d", minutes, seconds%60)
+}
+
+func formatBytes(n int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	size := float64(n)
+	unit := 0
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%v B", n)
+	}
+	return fmt.Sprintf("%.1f %v", size, units[unit])
+}
+
+func storeAttachments(ctx context.Context, s *state, feedID uuid.UUID, guid string, enclosures []RSSEnclosure) {
+	for _, enclosure := range enclosures {
+		params := database.CreateAttachmentParams{
+			ID: uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			Url: enclosure.URL,
+			MimeType: sql.NullString{
+				String: enclosure.Type,
+				Valid: enclosure.Type != "",
+			},
+			FeedID: feedID,
+			Guid: guid,
+		}
+		if length, err := strconv.ParseInt(strings.TrimSpace(enclosure.Length), 10, 64); err == nil && length > 0 {
+			params.Length = sql.NullInt64{
+				Int64: length,
+				Valid: true,
+			}
+		}
+		if duration, err := parseEpisodeDuration(enclosure.Duration); err == nil && duration > 0 {
+			params.DurationSeconds = sql.NullInt32{
+				Int32: int32(duration),
+				Valid: true,
+			}
+		}
+		err := s.db.CreateAttachment(ctx, params)
+		if err != nil {
+			log.Printf("error creating attachment in database: %v", err)
+		}
+	}
+}
+
+func HandlerEpisodes(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet("episodes", flag.ContinueOnError)
+	feedURL := flags.String("feed", "", "only show episodes from this feed")
+	pending := flags.Bool("pending", false, "only show episodes that have not been downloaded")
+	args, err := parseArguments(flags, cmd.Arguments)
+	if err != nil {
+		return fmt.Errorf("error parsing episodes flags: %v", err)
+	}
+	limit := 20
+	if len(args) > 0 {
+		limit, err = strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("error parsing limit argument: %v", err)
+		}
+	}
+	ctx := context.Background()
+	params := database.GetEpisodesByUserIDParams{
+		UserID: user.ID,
+		PendingOnly: *pending,
+		EpisodeLimit: int32(limit),
+	}
+	if *feedURL != "" {
+		feed, err := s.db.GetFeedByUrl(ctx, *feedURL)
+		if err != nil {
+			return fmt.Errorf("error retrieving feed from database: %v", err)
+		}
+		params.FeedID = uuid.NullUUID{
+			UUID: feed.ID,
+			Valid: true,
+		}
+	}
+	episodes, err := s.db.GetEpisodesByUserID(ctx, params)
+	if err != nil {
+		return fmt.Errorf("error retrieving episodes from database: %v", err)
+	}
+	if len(episodes) == 0 {
+		fmt.Println("no episodes found")
+		return nil
+	}
+	for _, episode := range episodes {
+		date := episode.CreatedAt
+		if episode.PublishedAt.Valid {
+			date = episode.PublishedAt.Time
+		}
+		meta := []string{episode.FeedName.String, date.Format("2006-01-02")}
+		if episode.DurationSeconds.Valid {
+			meta = append(meta, formatEpisodeDuration(episode.DurationSeconds.Int32))
+		}
+		if episode.Length.Valid {
+			meta = append(meta, formatBytes(episode.Length.Int64))
+		}
+		switch {
+		case episode.CompletedAt.Valid:
+			meta = append(meta, "downloaded")
+		case episode.BytesDownloaded.Int64 > 0:
+			meta = append(meta, "partial "+formatBytes(episode.BytesDownloaded.Int64))
+		default:
+			meta = append(meta, "new")
+		}
+		fmt.Printf("* %v\n", episode.Title.String)
+		fmt.Printf("  %v\n", strings.Join(meta, " | "))
+		fmt.Printf("  id: %v\n", episode.ID)
+		fmt.Printf("  %v\n", episode.Url)
+	}
+	return nil
+}
+
+func downloadDir(s *state, dir string, save bool) (string, error) {
+	if dir != "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return "", fmt.Errorf("error resolving download directory: %v", err)
+		}
+		if save && abs != s.cfg.DownloadDir {
+			err := s.cfg.SetDownloadDir(abs)
+			if err != nil {
+				return "", fmt.Errorf("error saving download directory: %v", err)
+			}
+		}
+		return abs, nil
+	}
+	if s.cfg.DownloadDir != "" {
+		return s.cfg.DownloadDir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting user home directory: %v", err)
+	}
+	return filepath.Join(homeDir, "gator-downloads"), nil
+}
+
+func safeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 32 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.Join(strings.Fields(name), " "), ". ")
+	runes := []rune(name)
+	if len(runes) > 120 {
+		name = strings.TrimSpace(string(runes[:120]))
+	}
+	return name
+}
+
+func episodePath(dir string, episode database.GetEpisodesByUserIDRow) string {
+	if episode.Path.Valid {
+		return episode.Path.String
+	}
+	feedName := safeFileName(episode.FeedName.String)
+	if feedName == "" {
+		feedName = "unknown feed"
+	}
+	date := episode.CreatedAt
+	if episode.PublishedAt.Valid {
+		date = episode.PublishedAt.Time
+	}
+	title := safeFileName(episode.Title.String)
+	if title == "" {
+		title = episode.ID.String()
+	}
+	ext := ""
+	if parsed, err := url.Parse(episode.Url); err == nil {
+		ext = path.Ext(parsed.Path)
+	}
+	if ext == "" && episode.MimeType.Valid {
+		if exts, err := mime.ExtensionsByType(episode.MimeType.String); err == nil && len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	return filepath.Join(dir, feedName, date.Format("2006-01-02")+" "+title+safeFileName(ext))
+}
+
+func recordDownload(s *state, user database.User, episode database.GetEpisodesByUserIDRow, download episodeDownload) {
+	params := database.UpsertAttachmentDownloadParams{
+		UserID: user.ID,
+		AttachmentID: episode.ID,
+		UpdatedAt: time.Now().UTC(),
+		Path: download.Path,
+		BytesDownloaded: download.Bytes,
+	}
+	if download.Err == nil {
+		params.CompletedAt = sql.NullTime{
+			Time: time.Now().UTC(),
+			Valid: true,
+		}
+	} else {
+		params.LastError = sql.NullString{
+			String: download.Err.Error(),
+			Valid: true,
+		}
+	}
+	err := s.db.UpsertAttachmentDownload(context.Background(), params)
+	if err != nil {
+		log.Printf("error recording download of %v: %v", episode.Url, err)
+	}
+}
+
+func downloadEpisode(ctx context.Context, s *state, user database.User, episode database.GetEpisodesByUserIDRow, dir string) episodeDownload {
+	download := episodeDownload{
+		Title: episode.Title.String,
+		Path: episodePath(dir, episode),
+	}
+	if download.Title == "" {
+		download.Title = episode.Url
+	}
+	if info, err := os.Stat(download.Path); err == nil && episode.CompletedAt.Valid {
+		download.Bytes = info.Size()
+		download.Skipped = true
+		return download
+	}
+	partial := download.Path + ".part"
+	download.Err = fetchEpisode(ctx, episode.Url, partial, &download.Bytes)
+	if download.Err == nil {
+		err := os.Rename(partial, download.Path)
+		if err != nil {
+			download.Err = fmt.Errorf("error moving finished download: %v", err)
+		}
+	}
+	recordDownload(s, user, episode, download)
+	return download
+}
+
+func fetchEpisode(ctx context.Context, episodeURL string, partial string, written *int64) error {
+	err := os.MkdirAll(filepath.Dir(partial), 0755)
+	if err != nil {
+		return fmt.Errorf("error creating download directory: %v", err)
+	}
+	var offset int64
+	if info, err := os.Stat(partial); err == nil {
+		offset = info.Size()
+	}
+	*written = offset
+	req, err := http.NewRequestWithContext(ctx, "GET", episodeURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating http request: %v", err)
+	}
+	req.Header.Set("User-Agent", "gator")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%v-", offset))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending http get request: %v", err)
+	}
+	defer resp.Body.Close()
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusOK:
+		flags |= os.O_TRUNC
+		offset = 0
+		*written = 0
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		return nil
+	default:
+		return fmt.Errorf("error retrieving %v: server response %v", episodeURL, resp.StatusCode)
+	}
+	file, err := os.OpenFile(partial, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening download file: %v", err)
+	}
+	n, err := io.Copy(file, resp.Body)
+	*written = offset + n
+	closeErr := file.Close()
+	if err != nil {
+		return fmt.Errorf("error downloading %v: %v", episodeURL, err)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("error writing download file: %v", closeErr)
+	}
+	return nil
+}
+
+func HandlerDownload(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet("download", flag.ContinueOnError)
+	dirFlag := flags.String("dir", "", "directory to save episodes in")
+	save := flags.Bool("save", false, "remember --dir as the default download directory")
+	concurrency := flags.Int("concurrency", 2, "number of episodes downloaded at the same time")
+	limit := flags.Int("limit", 10, "maximum number of pending episodes to download")
+	feedURL := flags.String("feed", "", "only download episodes from this feed")
+	args, err := parseArguments(flags, cmd.Arguments)
+	if err != nil {
+		return fmt.Errorf("error parsing download flags: %v", err)
+	}
+	if *concurrency < 1 || *limit < 1 {
+		return errors.New("concurrency and limit must be at least 1")
+	}
+	if *save && *dirFlag == "" {
+		return errors.New("--save needs a directory passed with --dir")
+	}
+	dir, err := downloadDir(s, *dirFlag, *save)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var episodes []database.GetEpisodesByUserIDRow
+	for _, arg := range args {
+		id, err := uuid.Parse(arg)
+		if err != nil {
+			return fmt.Errorf("invalid episode id: %v", arg)
+		}
+		episode, err := s.db.GetEpisodeByID(ctx, database.GetEpisodeByIDParams{ID: id, UserID: user.ID})
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no episode found with id: %v", arg)
+		}
+		if err != nil {
+			return fmt.Errorf("error retrieving episode from database: %v", err)
+		}
+		episodes = append(episodes, database.GetEpisodesByUserIDRow(episode))
+	}
+	if len(args) == 0 {
+		params := database.GetEpisodesByUserIDParams{
+			UserID: user.ID,
+			PendingOnly: true,
+			EpisodeLimit: int32(*limit),
+		}
+		if *feedURL != "" {
+			feed, err := s.db.GetFeedByUrl(ctx, *feedURL)
+			if err != nil {
+				return fmt.Errorf("error retrieving feed from database: %v", err)
+			}
+			params.FeedID = uuid.NullUUID{
+				UUID: feed.ID,
+				Valid: true,
+			}
+		}
+		episodes, err = s.db.GetEpisodesByUserID(ctx, params)
+		if err != nil {
+			return fmt.Errorf("error retrieving episodes from database: %v", err)
+		}
+	}
+	if len(episodes) == 0 {
+		fmt.Println("no episodes to download")
+		return nil
+	}
+	fmt.Printf("downloading %v episodes to %v\n", len(episodes), dir)
+	jobs := make(chan database.GetEpisodesByUserIDRow)
+	results := make(chan episodeDownload)
+	var wg sync.WaitGroup
+	for i := 0; i < *concurrency && i < len(episodes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for episode := range jobs {
+				results <- downloadEpisode(ctx, s, user, episode, dir)
+			}
+		}()
+	}
+	go func() {
+		for _, episode := range episodes {
+			jobs <- episode
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+	var downloaded, skipped, failed int
+	for result := range results {
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Printf("error downloading %v: %v\n", result.Title, result.Err)
+		case result.Skipped:
+			skipped++
+			fmt.Printf("already downloaded %v\n", result.Title)
+		default:
+			downloaded++
+			fmt.Printf("downloaded %v (%v) to %v\n", result.Title, formatBytes(result.Bytes), result.Path)
+		}
+	}
+	fmt.Printf("%v downloaded, %v already downloaded, %v failed\n", downloaded, skipped, failed)
+	if ctx.Err() != nil {
+		return errors.New("download interrupted, run download again to resume")
+	}
+	return nil
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lynn-Xy/bloggatog/internal/database"
+)
+
+type riverOptions struct {
+	Format string
+	Title string
+	Link string
+	Browse browseOptions
+}
+
+type atomOutLink struct {
+	Href string `xml:"href,attr"`
+	Rel string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomOutText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Text string `xml:",chardata"`
+}
+
+type atomOutCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomOutEntry struct {
+	Title string `xml:"title"`
+	ID string `xml:"id"`
+	Links []atomOutLink `xml:"link"`
+	Updated string `xml:"updated"`
+	Published string `xml:"published,omitempty"`
+	Author *struct {
+		Name string `xml:"name"`
+	} `xml:"author,omitempty"`
+	Categories []atomOutCategory `xml:"category"`
+	Summary *atomOutText `xml:"summary,omitempty"`
+	Source *struct {
+		Title string `xml:"title"`
+	} `xml:"source,omitempty"`
+}
+
+type atomOutFeed struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	Title string `xml:"title"`
+	ID string `xml:"id"`
+	Updated string `xml:"updated"`
+	Links []atomOutLink `xml:"link"`
+	Author struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Generator string `xml:"generator"`
+	Entries []atomOutEntry `xml:"entry"`
+}
+
+type rssOutGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value string `xml:",chardata"`
+}
+
+type rssOutItem struct {
+	Title string `xml:"title"`
+	Link string `xml:"link"`
+	Description string `xml:"description,omitempty"`
+	Creator string `xml:"dc:creator,omitempty"`
+	Categories []string `xml:"category"`
+	GUID rssOutGUID `xml:"guid"`
+	PubDate string `xml:"pubDate,omitempty"`
+}
+
+type rssOutFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string `xml:"version,attr"`
+	DCNamespace string `xml:"xmlns:dc,attr"`
+	Channel struct {
+		Title string `xml:"title"`
+		Link string `xml:"link"`
+		Description string `xml:"description"`
+		LastBuildDate string `xml:"lastBuildDate"`
+		Generator string `xml:"generator"`
+		Items []rssOutItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+func postDate(post database.GetXPostsByUserIDRow) time.Time {
+	if post.PublishedAt.Valid {
+		return post.PublishedAt.Time.UTC()
+	}
+	return post.CreatedAt.UTC()
+}
+
+func riverUpdated(posts []database.GetXPostsByUserIDRow) time.Time {
+	updated := time.Now().UTC()
+	if len(posts) > 0 {
+		updated = postDate(posts[0])
+	}
+	return updated
+}
+
+func buildAtomRiver(user database.User, options riverOptions, posts []database.GetXPostsByUserIDRow) atomOutFeed {
+	feed := atomOutFeed{
+		Title: options.Title,
+		ID: "urn:uuid:" + user.ID.String(),
+		Updated: riverUpdated(posts).Format(time.RFC3339),
+		Generator: "gator",
+	}
+	feed.Author.Name = user.Name
+	if options.Link != "" {
+		feed.Links = append(feed.Links, atomOutLink{
+			Href: options.Link,
+			Rel: "self",
+			Type: "application/atom+xml",
+		})
+	}
+	for _, post := range posts {
+		entry := atomOutEntry{
+			Title: post.Title.String,
+			ID: "urn:uuid:" + post.ID.String(),
+			Links: []atomOutLink{{Href: post.Url, Rel: "alternate"}},
+			Updated: post.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+		if post.PublishedAt.Valid {
+			entry.Published = post.PublishedAt.Time.UTC().Format(time.RFC3339)
+		}
+		if post.Author.String != "" {
+			entry.Author = &struct {
+				Name string `xml:"name"`
+			}{Name: post.Author.String}
+		}
+		for _, category := range post.Categories {
+			entry.Categories = append(entry.Categories, atomOutCategory{Term: category})
+		}
+		if post.Description.String != "" {
+			entry.Summary = &atomOutText{
+				Type: "html",
+				Text: post.Description.String,
+			}
+		}
+		if post.FeedName.String != "" {
+			entry.Source = &struct {
+				Title string `xml:"title"`
+			}{Title: post.FeedName.String}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
+
+func buildRSSRiver(user database.User, options riverOptions, posts []database.GetXPostsByUserIDRow) rssOutFeed {
+	feed := rssOutFeed{
+		Version: "2.0",
+		DCNamespace: "http://purl.org/dc/elements/1.1/",
+	}
+	feed.Channel.Title = options.Title
+	feed.Channel.Link = options.Link
+	feed.Channel.Description = fmt.Sprintf("Posts from feeds followed by %v", user.Name)
+	feed.Channel.LastBuildDate = riverUpdated(posts).Format(time.RFC1123Z)
+	feed.Channel.Generator = "gator"
+	for _, post := range posts {
+		item := rssOutItem{
+			Title: post.Title.String,
+			Link: post.Url,
+			Description: post.Description.String,
+			Creator: post.Author.String,
+			Categories: post.Categories,
+			GUID: rssOutGUID{
+				IsPermaLink: "false",
+				Value: "urn:uuid:" + post.ID.String(),
+			},
+			PubDate: postDate(post).Format(time.RFC1123Z),
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	return feed
+}
+
+func writeRiver(ctx context.Context, s *state, w io.Writer, user database.User, options riverOptions) (int, error) {
+	posts, err := browsePosts(ctx, s, user, options.Browse)
+	if err != nil {
+		return 0, err
+	}
+	if options.Title == "" {
+		options.Title = fmt.Sprintf("%v's gator river", user.Name)
+	}
+	var document any
+	switch options.Format {
+	case "atom":
+		document = buildAtomRiver(user, options, posts)
+	case "rss":
+		document = buildRSSRiver(user, options, posts)
+	default:
+		return 0, fmt.Errorf("unknown feed format: %v (expected atom or rss)", options.Format)
+	}
+	data, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("error marshaling %v feed: %v", options.Format, err)
+	}
+	_, err = io.WriteString(w, xml.Header+string(data)+"\n")
+	if err != nil {
+		return 0, fmt.Errorf("error writing %v feed: %v", options.Format, err)
+	}
+	return len(posts), nil
+}
+
+func HandlerPublish(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet("publish", flag.ContinueOnError)
+	format := flags.String("format", "atom", "output format: atom or rss")
+	folderName := flags.String("folder", "", "only include posts from feeds in this folder")
+	query := flags.String("query", "", "only include posts matching this search query")
+	limit := flags.Int("limit", 50, "maximum number of posts")
+	title := flags.String("title", "", "title of the published feed")
+	link := flags.String("link", "", "url the published feed will be served from (required)")
+	args, err := parseArguments(flags, cmd.Arguments)
+	if err != nil {
+		return fmt.Errorf("error parsing publish flags: %v", err)
+	}
+	if *limit < 1 {
+		return errors.New("limit must be at least 1")
+	}
+	if *link == "" {
+		return errors.New("--link must be set to the url the published feed will be served from")
+	}
+	options := riverOptions{
+		Format: *format,
+		Title: *title,
+		Link: *link,
+		Browse: browseOptions{
+			Folder: *folderName,
+			Query: *query,
+			Limit: int32(*limit),
+		},
+	}
+	var out io.Writer = os.Stdout
+	if len(args) > 0 {
+		file, err := os.Create(args[0])
+		if err != nil {
+			return fmt.Errorf("error creating publish file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	count, err := writeRiver(context.Background(), s, out, user, options)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		fmt.Printf("published %v posts to %v\n", count, args[0])
+	}
+	return nil
+}
+
+func HandlerRiverToken(s *state, cmd command, user database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return errors.New("usage: rivertoken create [--base url] | revoke")
+	}
+	ctx := context.Background()
+	switch cmd.Arguments[0] {
+	case "create":
+		flags := flag.NewFlagSet("rivertoken create", flag.ContinueOnError)
+		base := flags.String("base", "http://localhost:8080", "public url of the serve command")
+		_, err := parseArguments(flags, cmd.Arguments[1:])
+		if err != nil {
+			return fmt.Errorf("error parsing rivertoken flags: %v", err)
+		}
+		token, err := generateSecret(riverTokenPrefix)
+		if err != nil {
+			return err
+		}
+		err = s.db.UpsertRiverToken(ctx, database.UpsertRiverTokenParams{
+			UserID: user.ID,
+			CreatedAt: time.Now().UTC(),
+			TokenHash: hashApiKey(token),
+		})
+		if err != nil {
+			return fmt.Errorf("error storing river token in database: %v", err)
+		}
+		fmt.Printf("feed url: %v/river/%v\n", strings.TrimRight(*base, "/"), token)
+		fmt.Println("anyone with this url can read your river, store it now, it cannot be shown again")
+		fmt.Println("any previous river url no longer works")
+	case "revoke":
+		deleted, err := s.db.DeleteRiverTokenByUserID(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("error revoking river token: %v", err)
+		}
+		if deleted == 0 {
+			return errors.New("no river token to revoke")
+		}
+		fmt.Println("revoked river url")
+	default:
+		return fmt.Errorf("unknown rivertoken subcommand: %v", cmd.Arguments[0])
+	}
+	return nil
+}
+
+func riverTokenHandler(s *state) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := s.db.GetUserByRiverTokenHash(r.Context(), hashApiKey(r.PathValue("token")))
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "unknown river url")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("error retrieving river token from database: %v", err))
+			return
+		}
+		apiGetRiver(s, w, r, user)
+	}
+}
+
+func apiGetRiver(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	query := r.URL.Query()
+	options := riverOptions{
+		Format: query.Get("format"),
+		Title: query.Get("title"),
+		Browse: browseOptions{
+			Folder: query.Get("folder"),
+			Query: query.Get("q"),
+			Limit: 50,
+		},
+	}
+	if options.Format == "" {
+		options.Format = "atom"
+	}
+	if options.Format != "atom" && options.Format != "rss" {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown feed format: %v (expected atom or rss)", options.Format))
+		return
+	}
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit: %v", value))
+			return
+		}
+		options.Browse.Limit = int32(n)
+	}
+	self := *r.URL
+	self.Scheme = "http"
+	if r.TLS != nil {
+		self.Scheme = "https"
+	}
+	self.Host = r.Host
+	options.Link = self.String()
+	contentType := "application/atom+xml; charset=utf-8"
+	if options.Format == "rss" {
+		contentType = "application/rss+xml; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, err := writeRiver(r.Context(), s, w, user, options)
+	if err != nil {
+		w.Header().Del("Content-Type")
+		respondError(w, errorStatus(err), err.Error())
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+type rdfItem struct {
+	About string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title string `xml:"title"`
+	Link string `xml:"link"`
+	Description string `xml:"description"`
+	Date string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creator string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Subjects []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+}
+
+type rdfFeed struct {
+	XMLName xml.Name `xml:"RDF"`
+	Channel struct {
+		Title string `xml:"title"`
+		Link string `xml:"link"`
+		Description string `xml:"description"`
+	} `xml:"channel"`
+	Item []rdfItem `xml:"item"`
+}
+
+func parseRDFFeed(data []byte) (*RSSFeed, error) {
+	var rdf rdfFeed
+	err := xml.Unmarshal(data, &rdf)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing rdf feed: %v", err)
+	}
+	var feed RSSFeed
+	feed.Channel.Title = rdf.Channel.Title
+	feed.Channel.Link = rdf.Channel.Link
+	feed.Channel.Description = rdf.Channel.Description
+	for _, entry := range rdf.Item {
+		item := RSSItem{
+			Title: entry.Title,
+			Link: entry.Link,
+			Description: entry.Description,
+			PubDate: entry.Date,
+			GUID: entry.About,
+			Author: entry.Creator,
+			Categories: entry.Subjects,
+		}
+		if item.Link == "" {
+			item.Link = entry.About
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+	return &feed, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Lynn-Xy/bloggatog/internal/database"
+	"github.com/google/uuid"
+)
+
+type readTarget struct {
+	All bool
+	PostID uuid.UUID
+	FeedID uuid.UUID
+}
+
+func findPost(ctx context.Context, s *state, user database.User, argument string) (database.Post, error) {
+	if id, err := uuid.Parse(argument); err == nil {
+		post, err := s.db.GetFollowedPostByID(ctx, database.GetFollowedPostByIDParams{
+			ID: id,
+			UserID: user.ID,
+		})
+		if err == nil {
+			return post, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return database.Post{}, fmt.Errorf("error retrieving post from database: %v", err)
+		}
+	}
+	posts, err := s.db.GetFollowedPostsByUrl(ctx, database.GetFollowedPostsByUrlParams{
+		Url: argument,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return database.Post{}, fmt.Errorf("error retrieving post from database: %v", err)
+	}
+	if len(posts) == 0 {
+		return database.Post{}, sql.ErrNoRows
+	}
+	if len(posts) > 1 {
+		ids := make([]string, len(posts))
+		for idx, post := range posts {
+			ids[idx] = post.ID.String()
+		}
+		return database.Post{}, &ambiguousError{fmt.Sprintf("%v matches several posts, use one of their ids instead: %v", argument, strings.Join(ids, ", "))}
+	}
+	return posts[0], nil
+}
+
+func resolveReadTarget(ctx context.Context, s *state, user database.User, argument string) (readTarget, error) {
+	if argument == "all" {
+		return readTarget{All: true}, nil
+	}
+	post, err := findPost(ctx, s, user, argument)
+	if err == nil {
+		return readTarget{PostID: post.ID}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return readTarget{}, err
+	}
+	feed, err := userFeedByUrl(ctx, s, user, argument)
+	if err == nil {
+		return readTarget{FeedID: feed.ID}, nil
+	}
+	var notFound *notFoundError
+	if !errors.As(err, &notFound) {
+		return readTarget{}, err
+	}
+	return readTarget{}, &notFoundError{fmt.Sprintf("no post or followed feed found matching: %v", argument)}
+}
+
+func HandlerRead(s *state, cmd command, user database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return errors.New("post id, post url, feed url or \"all\" must be provided")
+	}
+	ctx := context.Background()
+	target, err := resolveReadTarget(ctx, s, user, cmd.Arguments[0])
+	if err != nil {
+		return err
+	}
+	marked, err := markTargetRead(ctx, s, user, target)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("marked %v posts as read\n", marked)
+	return nil
+}
+
+func markTargetRead(ctx context.Context, s *state, user database.User, target readTarget) (int64, error) {
+	now := time.Now().UTC()
+	var marked int64
+	var err error
+	switch {
+	case target.All:
+		marked, err = s.db.MarkAllRead(ctx, database.MarkAllReadParams{
+			ReadAt: now,
+			UserID: user.ID,
+		})
+	case target.FeedID != uuid.Nil:
+		marked, err = s.db.MarkFeedRead(ctx, database.MarkFeedReadParams{
+			UserID: user.ID,
+			ReadAt: now,
+			FeedID: target.FeedID,
+		})
+	default:
+		marked, err = s.db.MarkPostRead(ctx, database.MarkPostReadParams{
+			UserID: user.ID,
+			PostID: target.PostID,
+			ReadAt: now,
+		})
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error marking posts as read: %v", err)
+	}
+	return marked, nil
+}
+
+func HandlerUnread(s *state, cmd command, user database.User) error {
+	if len(cmd.Arguments) < 1 {
+		return errors.New("post id, post url, feed url or \"all\" must be provided")
+	}
+	ctx := context.Background()
+	target, err := resolveReadTarget(ctx, s, user, cmd.Arguments[0])
+	if err != nil {
+		return err
+	}
+	marked, err := markTargetUnread(ctx, s, user, target)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("marked %v posts as unread\n", marked)
+	return nil
+}
+
+func markTargetUnread(ctx context.Context, s *state, user database.User, target readTarget) (int64, error) {
+	var marked int64
+	var err error
+	switch {
+	case target.All:
+		marked, err = s.db.MarkAllUnread(ctx, user.ID)
+	case target.FeedID != uuid.Nil:
+		marked, err = s.db.MarkFeedUnread(ctx, database.MarkFeedUnreadParams{
+			UserID: user.ID,
+			FeedID: target.FeedID,
+		})
+	default:
+		marked, err = s.db.MarkPostUnread(ctx, database.MarkPostUnreadParams{
+			UserID: user.ID,
+			PostID: target.PostID,
+		})
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error marking posts as unread: %v", err)
+	}
+	return marked, nil
+}
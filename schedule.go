@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPollInterval = time.Hour
+	recentPostSample = 10
+)
+
+type pollPolicy struct {
+	MinInterval time.Duration
+	MaxInterval time.Duration
+}
+
+func (p pollPolicy) clamp(interval time.Duration) time.Duration {
+	if interval < p.MinInterval {
+		return p.MinInterval
+	}
+	if interval > p.MaxInterval {
+		return p.MaxInterval
+	}
+	return interval
+}
+
+func postingInterval(postDates []time.Time) time.Duration {
+	if len(postDates) < 2 {
+		return defaultPollInterval
+	}
+	newest := postDates[0]
+	oldest := postDates[len(postDates)-1]
+	average := newest.Sub(oldest) / time.Duration(len(postDates)-1)
+	return average / 2
+}
+
+func nextFetchTime(now time.Time, policy pollPolicy, response *feedResponse, postDates []time.Time) time.Time {
+	interval := postingInterval(postDates)
+	if response != nil {
+		if response.Feed != nil {
+			ttl, err := strconv.Atoi(strings.TrimSpace(response.Feed.Channel.TTL))
+			if err == nil && time.Duration(ttl)*time.Minute > interval {
+				interval = time.Duration(ttl) * time.Minute
+			}
+		}
+		if response.MaxAge > interval {
+			interval = response.MaxAge
+		}
+	}
+	next := now.Add(policy.clamp(interval))
+	next = honorRetryAfter(now, next, policy, response)
+	if response != nil && response.Feed != nil {
+		next = skipUnavailable(next, response.Feed.Channel.SkipHours, response.Feed.Channel.SkipDays)
+	}
+	return next
+}
+
+func backoffFetchTime(now time.Time, policy pollPolicy, response *feedResponse, failures int) time.Time {
+	interval := policy.MinInterval
+	for i := 1; i < failures && interval < policy.MaxInterval; i++ {
+		interval *= 2
+	}
+	next := now.Add(policy.clamp(interval))
+	next = honorRetryAfter(now, next, policy, response)
+	return next
+}
+
+func honorRetryAfter(now time.Time, next time.Time, policy pollPolicy, response *feedResponse) time.Time {
+	if response == nil || !response.RetryAfter.After(next) {
+		return next
+	}
+	if limit := now.Add(policy.MaxInterval); response.RetryAfter.After(limit) {
+		return limit
+	}
+	return response.RetryAfter
+}
+
+func skipUnavailable(next time.Time, skipHours []string, skipDays []string) time.Time {
+	hours := make(map[int]bool)
+	for _, hour := range skipHours {
+		value, err := strconv.Atoi(strings.TrimSpace(hour))
+		if err == nil {
+			hours[value%24] = true
+		}
+	}
+	days := make(map[string]bool)
+	for _, day := range skipDays {
+		days[strings.ToLower(strings.TrimSpace(day))] = true
+	}
+	if len(hours) == 0 && len(days) == 0 {
+		return next
+	}
+	for i := 0; i < 7*24; i++ {
+		utc := next.UTC()
+		if !hours[utc.Hour()] && !days[strings.ToLower(utc.Weekday().String())] {
+			return next
+		}
+		next = utc.Truncate(time.Hour).Add(time.Hour)
+	}
+	return next
+}
+
+func parseMaxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(directive), "=")
+		if !found || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+		if err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return 0
+}
+
+func parseRetryAfter(retryAfter string, now time.Time) time.Time {
+	retryAfter = strings.TrimSpace(retryAfter)
+	if retryAfter == "" {
+		return time.Time{}
+	}
+	seconds, err := strconv.Atoi(retryAfter)
+	if err == nil {
+		return now.Add(time.Duration(seconds) * time.Second)
+	}
+	date, err := http.ParseTime(retryAfter)
+	if err == nil {
+		return date
+	}
+	return time.Time{}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Lynn-Xy/bloggatog/internal/database"
+	"github.com/google/uuid"
+)
+
+var snippetTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+func terminalSnippet(snippet string) string {
+	snippet = strings.ReplaceAll(snippet, "<mark>", "\x00")
+	snippet = strings.ReplaceAll(snippet, "</mark>", "\x01")
+	snippet = snippetTagPattern.ReplaceAllString(snippet, "")
+	snippet = strings.Join(strings.Fields(snippet), " ")
+	snippet = strings.ReplaceAll(snippet, "\x00", "\033[1m")
+	return strings.ReplaceAll(snippet, "\x01", "\033[0m")
+}
+
+func HandlerSearch(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	feedURL := flags.String("feed", "", "only search posts from the feed with this url")
+	since := flags.String("since", "", "only search posts published on or after this date")
+	until := flags.String("until", "", "only search posts published before this date")
+	limit := flags.Int("limit", 10, "maximum number of results")
+	args, err := parseArguments(flags, cmd.Arguments)
+	if err != nil {
+		return fmt.Errorf("error parsing search flags: %v", err)
+	}
+	if len(args) < 1 {
+		return errors.New("search query must be provided")
+	}
+	ctx := context.Background()
+	params := database.SearchPostsByUserIDParams{
+		Query: strings.Join(args, " "),
+		UserID: user.ID,
+		ResultLimit: int32(*limit),
+	}
+	if *feedURL != "" {
+		feed, err := s.db.GetFeedByUrl(ctx, *feedURL)
+		if err != nil {
+			return fmt.Errorf("error retrieving feed from database: %v", err)
+		}
+		params.FeedID = uuid.NullUUID{
+			UUID: feed.ID,
+			Valid: true,
+		}
+	}
+	if *since != "" {
+		date, err := parseDate(*since)
+		if err != nil {
+			return fmt.Errorf("error parsing since date: %v", err)
+		}
+		params.Since = sql.NullTime{
+			Time: date,
+			Valid: true,
+		}
+	}
+	if *until != "" {
+		date, err := parseDate(*until)
+		if err != nil {
+			return fmt.Errorf("error parsing until date: %v", err)
+		}
+		params.Until = sql.NullTime{
+			Time: date,
+			Valid: true,
+		}
+	}
+	results, err := s.db.SearchPostsByUserID(ctx, params)
+	if err != nil {
+		return fmt.Errorf("error searching posts: %v", err)
+	}
+	if len(results) == 0 {
+		fmt.Println("no matching posts found")
+		return nil
+	}
+	for _, result := range results {
+		date := result.CreatedAt
+		if result.PublishedAt.Valid {
+			date = result.PublishedAt.Time
+		}
+		fmt.Printf("* %v\n", result.Title.String)
+		fmt.Printf("  %v | %v | %v\n", result.FeedName.String, date.Format("2006-01-02"), result.Url)
+		fmt.Printf("  %v\n", terminalSnippet(result.Snippet))
+	}
+	return nil
+}
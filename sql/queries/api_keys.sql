@@ -0,0 +1,31 @@
+-- name: CreateApiKey :one
+INSERT INTO api_keys (id, created_at, user_id, name, key_hash)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: GetUserByApiKeyHash :one
+SELECT users.id, users.created_at, users.updated_at, users.name
+FROM api_keys
+INNER JOIN users ON api_keys.user_id = users.id
+WHERE api_keys.key_hash = $1;
+
+-- name: TouchApiKeyByHash :exec
+UPDATE api_keys
+SET last_used_at = $1
+WHERE key_hash = $2;
+
+-- name: GetApiKeysByUserID :many
+SELECT id, created_at, name, last_used_at
+FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: DeleteApiKeyByID :execrows
+DELETE FROM api_keys
+WHERE id = $1 AND user_id = $2;
@@ -0,0 +1,49 @@
+-- name: CreateAttachment :exec
+INSERT INTO attachments (id, created_at, updated_at, post_id, url, mime_type, length, duration_seconds)
+SELECT sqlc.arg(id)::uuid, sqlc.arg(created_at)::timestamp, sqlc.arg(updated_at)::timestamp, posts.id, sqlc.arg(url)::text, sqlc.narg(mime_type)::text, sqlc.narg(length)::bigint, sqlc.narg(duration_seconds)::integer
+FROM posts
+WHERE posts.feed_id = sqlc.arg(feed_id) AND posts.guid = sqlc.arg(guid)
+ON CONFLICT (post_id, url) DO NOTHING;
+
+-- name: GetEpisodesByUserID :many
+SELECT attachments.id, attachments.url, attachments.mime_type, attachments.length, attachments.duration_seconds, posts.title, posts.published_at, posts.created_at, feeds.name AS feed_name, attachment_downloads.path, attachment_downloads.bytes_downloaded, attachment_downloads.completed_at
+FROM attachments
+INNER JOIN posts ON attachments.post_id = posts.id
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN attachment_downloads ON attachment_downloads.attachment_id = attachments.id
+    AND attachment_downloads.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+AND (NOT sqlc.arg(pending_only)::boolean OR attachment_downloads.completed_at IS NULL)
+ORDER BY posts.published_at DESC NULLS LAST, posts.created_at DESC
+LIMIT sqlc.arg(episode_limit);
+
+-- name: GetEpisodeByID :one
+SELECT attachments.id, attachments.url, attachments.mime_type, attachments.length, attachments.duration_seconds, posts.title, posts.published_at, posts.created_at, feeds.name AS feed_name, attachment_downloads.path, attachment_downloads.bytes_downloaded, attachment_downloads.completed_at
+FROM attachments
+INNER JOIN posts ON attachments.post_id = posts.id
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN attachment_downloads ON attachment_downloads.attachment_id = attachments.id
+    AND attachment_downloads.user_id = feed_follows.user_id
+WHERE attachments.id = $1
+AND feed_follows.user_id = $2;
+
+-- name: UpsertAttachmentDownload :exec
+INSERT INTO attachment_downloads (user_id, attachment_id, updated_at, path, bytes_downloaded, completed_at, last_error)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+ON CONFLICT (user_id, attachment_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    path = EXCLUDED.path,
+    bytes_downloaded = EXCLUDED.bytes_downloaded,
+    completed_at = EXCLUDED.completed_at,
+    last_error = EXCLUDED.last_error;
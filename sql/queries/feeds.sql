@@ -1,12 +1,13 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, site_url)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7
 )
 RETURNING *;
 
@@ -19,6 +20,17 @@ SELECT id, created_at, updated_at, name, url, user_id
 FROM feeds
 WHERE Url = $1;
 
+-- name: GetUserFeedByUrl :one
+SELECT id, created_at, updated_at, name, url, user_id
+FROM feeds
+WHERE url = sqlc.arg(url)
+AND (user_id = sqlc.arg(user_id) OR EXISTS (
+    SELECT 1
+    FROM feed_follows
+    WHERE feed_follows.feed_id = feeds.id
+    AND feed_follows.user_id = sqlc.arg(user_id)
+));
+
 -- name: GetFeedNameByFeedID :one
 SELECT name
 FROM feeds
@@ -29,9 +41,78 @@ UPDATE feeds
 SET last_fetched_at = $1, updated_at = $2
 WHERE id = $3;
 
--- name: GetNextFeedToFetchByUserID :one
-SELECT id, created_at, updated_at, name, url, user_id
+-- name: UpdateFeedCacheHeadersByID :exec
+UPDATE feeds
+SET etag = $1, last_modified = $2, updated_at = $3
+WHERE id = $4;
+
+-- name: ClaimDueFeeds :many
+UPDATE feeds
+SET last_fetched_at = sqlc.arg(fetched_at), updated_at = sqlc.arg(fetched_at), next_fetch_at = sqlc.arg(lease_until)
+WHERE id IN (
+    SELECT id
+    FROM feeds
+    WHERE EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = feeds.id
+    )
+    AND disabled_at IS NULL
+    AND (next_fetch_at IS NULL OR next_fetch_at <= sqlc.arg(fetched_at))
+    ORDER BY next_fetch_at ASC NULLS FIRST
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, etag, last_modified, fetch_full_content, legacy_guids;
+
+-- name: SetFeedNextFetchByID :exec
+UPDATE feeds
+SET next_fetch_at = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: RecordFeedSuccessByID :exec
+UPDATE feeds
+SET last_status = $1, last_error = NULL, consecutive_failures = 0, next_fetch_at = $2, updated_at = $3
+WHERE id = $4;
+
+-- name: RecordFeedFailureByID :one
+UPDATE feeds
+SET last_status = $1, last_error = $2, consecutive_failures = consecutive_failures + 1, updated_at = $3
+WHERE id = $4
+RETURNING consecutive_failures;
+
+-- name: DisableFeedByID :exec
+UPDATE feeds
+SET disabled_at = $1, next_fetch_at = NULL, updated_at = $2
+WHERE id = $3;
+
+-- name: EnableFeedByID :exec
+UPDATE feeds
+SET disabled_at = NULL, consecutive_failures = 0, next_fetch_at = NULL, updated_at = $1
+WHERE id = $2;
+
+-- name: GetUnhealthyFeeds :many
+SELECT id, name, url, last_fetched_at, next_fetch_at, last_status, last_error, consecutive_failures, disabled_at
 FROM feeds
-WHERE user_id = $1
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1;
+WHERE consecutive_failures > 0 OR disabled_at IS NOT NULL
+ORDER BY disabled_at ASC NULLS LAST, consecutive_failures DESC;
+
+-- name: UpdateFeedSiteUrlByID :exec
+UPDATE feeds
+SET site_url = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: GetFeedStatuses :many
+SELECT id, name, url, last_fetched_at, next_fetch_at, last_status, last_error, consecutive_failures, disabled_at
+FROM feeds
+ORDER BY name ASC;
+
+-- name: SetFeedFullContentByID :exec
+UPDATE feeds
+SET fetch_full_content = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: ClearFeedLegacyGuidsByID :exec
+UPDATE feeds
+SET legacy_guids = false, updated_at = $1
+WHERE id = $2;
@@ -0,0 +1,53 @@
+-- name: CreateFilterRule :one
+INSERT INTO filter_rules (id, created_at, updated_at, user_id, feed_id, match_type, pattern, action)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING *;
+
+-- name: GetFilterRulesByUserID :many
+SELECT filter_rules.id, filter_rules.match_type, filter_rules.pattern, filter_rules.action, feeds.url AS feed_url
+FROM filter_rules
+LEFT JOIN feeds ON filter_rules.feed_id = feeds.id
+WHERE filter_rules.user_id = $1
+ORDER BY filter_rules.created_at ASC;
+
+-- name: GetFilterRuleByID :one
+SELECT *
+FROM filter_rules
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteFilterRuleByID :execrows
+DELETE FROM filter_rules
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetPostsMatchingFilter :many
+SELECT posts.id, posts.title, posts.url, posts.author, posts.categories, feeds.name AS feed_name
+FROM feed_follows
+INNER JOIN posts ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+AND CASE sqlc.arg(match_type)::text
+    WHEN 'keyword' THEN strpos(lower(COALESCE(posts.title, '') || ' ' || COALESCE(posts.description, '')), lower(sqlc.arg(pattern)::text)) > 0
+    WHEN 'regex' THEN COALESCE(posts.title, '') || ' ' || COALESCE(posts.description, '') ~* sqlc.arg(pattern)::text
+    WHEN 'author' THEN lower(COALESCE(posts.author, '')) = lower(sqlc.arg(pattern)::text)
+    WHEN 'category' THEN EXISTS (
+        SELECT 1
+        FROM unnest(posts.categories) AS category
+        WHERE lower(category) = lower(sqlc.arg(pattern)::text)
+    )
+    ELSE false
+END
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC
+LIMIT sqlc.arg(post_limit);
+
+-- name: ValidateRegexPattern :exec
+SELECT '' ~* sqlc.arg(pattern)::text;
@@ -0,0 +1,43 @@
+-- name: CreateFolder :one
+INSERT INTO folders (id, created_at, updated_at, user_id, name)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: GetOrCreateFolder :one
+INSERT INTO folders (id, created_at, updated_at, user_id, name)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+ON CONFLICT (user_id, name) DO UPDATE
+SET updated_at = folders.updated_at
+RETURNING *;
+
+-- name: GetFolderByName :one
+SELECT *
+FROM folders
+WHERE user_id = $1 AND name = $2;
+
+-- name: GetFoldersByUserID :many
+SELECT *
+FROM folders
+WHERE user_id = $1
+ORDER BY name ASC;
+
+-- name: RenameFolderByID :exec
+UPDATE folders
+SET name = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: DeleteFolderByID :exec
+DELETE FROM folders
+WHERE id = $1;
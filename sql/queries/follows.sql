@@ -15,10 +15,32 @@ INNER JOIN feeds ON inserted_feed_follow.feed_id = feeds.id
 INNER JOIN users ON inserted_feed_follow.user_id = users.id;
 
 -- name: GetFeedFollowForUser :many
-SELECT id, created_at, updated_at, user_id, feed_id
+SELECT id, created_at, updated_at, user_id, feed_id, folder_id
 FROM feed_follows
 WHERE user_id = $1;
 
 -- name: UnfollowFeedByID :exec
 DELETE FROM feed_follows
 WHERE user_id = $1 AND feed_id = $2;
+
+-- name: CreateFeedFollowIfMissing :execrows
+INSERT INTO feed_follows (user_id, feed_id, folder_id)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id, feed_id) DO NOTHING;
+
+-- name: GetFollowedFeedsByUserID :many
+SELECT feeds.name, feeds.url, feeds.site_url, folders.name AS folder_name, feed_follows.feed_id
+FROM feed_follows
+INNER JOIN feeds ON feed_follows.feed_id = feeds.id
+LEFT JOIN folders ON feed_follows.folder_id = folders.id
+WHERE feed_follows.user_id = $1
+ORDER BY folders.name ASC NULLS FIRST, feeds.name ASC;
+
+-- name: SetFeedFollowFolder :execrows
+UPDATE feed_follows
+SET folder_id = $1, updated_at = $2
+WHERE user_id = $3 AND feed_id = $4;
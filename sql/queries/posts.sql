@@ -1,22 +1,130 @@
--- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES (
-    $1,
-    $2,
-    $3,
-    $4,
-    $5,
-    $6,
-    $7,
-    $8
-    )
-RETURNING *;
+-- name: UpsertPost :one
+WITH previous AS (
+    SELECT posts.id, posts.title, posts.url, posts.description, posts.author, posts.categories, posts.content_hash, posts.content
+    FROM posts
+    WHERE posts.feed_id = $8 AND posts.guid = $12
+),
+upserted AS (
+    INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, author, published_raw, categories, guid, content_hash)
+    VALUES (
+        $1,
+        $2,
+        $3,
+        $4,
+        $5,
+        $6,
+        $7,
+        $8,
+        $9,
+        $10,
+        $11,
+        $12,
+        $13
+        )
+    ON CONFLICT (feed_id, guid) DO UPDATE
+    SET updated_at = EXCLUDED.updated_at,
+        title = EXCLUDED.title,
+        url = EXCLUDED.url,
+        description = EXCLUDED.description,
+        author = EXCLUDED.author,
+        categories = EXCLUDED.categories,
+        content_hash = EXCLUDED.content_hash,
+        edited_at = CASE
+            WHEN posts.content_hash IS NOT NULL AND posts.content_hash <> EXCLUDED.content_hash THEN EXCLUDED.updated_at
+            ELSE posts.edited_at
+        END
+    WHERE (posts.title, posts.url, posts.description, posts.author, posts.categories, posts.content_hash)
+        IS DISTINCT FROM (EXCLUDED.title, EXCLUDED.url, EXCLUDED.description, EXCLUDED.author, EXCLUDED.categories, EXCLUDED.content_hash)
+    RETURNING posts.id, posts.url, (xmax = 0)::boolean AS inserted, posts.content_hash
+),
+revision AS (
+    INSERT INTO post_revisions (created_at, post_id, title, url, description, author, categories, content_hash, content)
+    SELECT $3, previous.id, previous.title, previous.url, previous.description, previous.author, previous.categories, previous.content_hash, previous.content
+    FROM previous
+    INNER JOIN upserted ON previous.id = upserted.id
+    WHERE previous.content_hash IS NOT NULL AND previous.content_hash <> upserted.content_hash
+)
+SELECT upserted.id, upserted.url, upserted.inserted,
+    COALESCE(previous.content_hash IS NOT NULL AND previous.content_hash <> upserted.content_hash, false)::boolean AS edited
+FROM upserted
+LEFT JOIN previous ON previous.id = upserted.id;
 
 -- name: GetXPostsByUserID :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.published_raw, posts.categories, posts.content, posts.edited_at, post_reads.read_at, COALESCE(filters.muted, false)::boolean AS muted, feeds.name AS feed_name, post_stars.starred_at
 FROM posts
 INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds ON posts.feed_id = feeds.id
 INNER JOIN users ON feed_follows.user_id = users.id
-WHERE users.id = $1
-ORDER BY published_at DESC
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = users.id
+LEFT JOIN post_stars ON post_stars.post_id = posts.id AND post_stars.user_id = users.id
+LEFT JOIN LATERAL (
+    SELECT bool_or(filter_rules.action = 'hide') AS hidden, bool_or(filter_rules.action = 'read') AS muted
+    FROM filter_rules
+    WHERE filter_rules.user_id = users.id
+    AND (filter_rules.feed_id IS NULL OR filter_rules.feed_id = posts.feed_id)
+    AND CASE filter_rules.match_type
+        WHEN 'keyword' THEN strpos(lower(COALESCE(posts.title, '') || ' ' || COALESCE(posts.description, '')), lower(filter_rules.pattern)) > 0
+        WHEN 'regex' THEN COALESCE(posts.title, '') || ' ' || COALESCE(posts.description, '') ~* filter_rules.pattern
+        WHEN 'author' THEN lower(COALESCE(posts.author, '')) = lower(filter_rules.pattern)
+        WHEN 'category' THEN EXISTS (
+            SELECT 1
+            FROM unnest(posts.categories) AS category
+            WHERE lower(category) = lower(filter_rules.pattern)
+        )
+        ELSE false
+    END
+) AS filters ON true
+WHERE users.id = sqlc.arg(id)
+AND NOT COALESCE(filters.hidden, false)
+AND (NOT sqlc.arg(unread_only)::boolean OR (post_reads.read_at IS NULL AND NOT COALESCE(filters.muted, false)))
+AND (sqlc.narg(folder_id)::uuid IS NULL OR feed_follows.folder_id = sqlc.narg(folder_id))
+AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+AND (sqlc.narg(query)::text IS NULL OR posts.search_vector @@ websearch_to_tsquery('english', sqlc.narg(query)))
+AND (NOT sqlc.arg(edited_only)::boolean OR posts.edited_at IS NOT NULL)
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC
+LIMIT sqlc.arg(post_limit)
+OFFSET sqlc.arg(post_offset);
+
+-- name: GetRecentPostDatesByFeedID :many
+SELECT COALESCE(published_at, created_at)::timestamp AS post_date
+FROM posts
+WHERE feed_id = $1
+ORDER BY post_date DESC
 LIMIT $2;
+
+-- name: GetFollowedPostByID :one
+SELECT posts.*
+FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE posts.id = $1 AND feed_follows.user_id = $2;
+
+-- name: GetFollowedPostsByUrl :many
+SELECT posts.*
+FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE posts.url = $1 AND feed_follows.user_id = $2
+ORDER BY posts.created_at ASC;
+
+-- name: UpdatePostContentByID :exec
+UPDATE posts
+SET content = $1, updated_at = $2
+WHERE id = $3;
+
+-- name: AdoptLegacyPostGuid :execrows
+UPDATE posts
+SET guid = sqlc.arg(guid)
+WHERE feed_id = sqlc.arg(feed_id)
+AND url = sqlc.arg(url)
+AND guid = url
+AND NOT EXISTS (
+    SELECT 1
+    FROM posts AS existing
+    WHERE existing.feed_id = sqlc.arg(feed_id)
+    AND existing.guid = sqlc.arg(guid)
+);
+
+-- name: GetPostRevisionsByPostID :many
+SELECT *
+FROM post_revisions
+WHERE post_id = $1
+ORDER BY created_at ASC;
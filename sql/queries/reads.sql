@@ -0,0 +1,38 @@
+-- name: MarkPostRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostUnread :execrows
+DELETE FROM post_reads
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: MarkFeedRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT sqlc.arg(user_id)::uuid, posts.id, sqlc.arg(read_at)::timestamp
+FROM posts
+WHERE posts.feed_id = sqlc.arg(feed_id)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkFeedUnread :execrows
+DELETE FROM post_reads
+USING posts
+WHERE post_reads.post_id = posts.id
+AND post_reads.user_id = $1
+AND posts.feed_id = $2;
+
+-- name: MarkAllRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, sqlc.arg(read_at)::timestamp
+FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkAllUnread :execrows
+DELETE FROM post_reads
+WHERE user_id = $1;
@@ -0,0 +1,19 @@
+-- name: UpsertRiverToken :exec
+INSERT INTO river_tokens (user_id, created_at, token_hash)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id) DO UPDATE
+SET created_at = EXCLUDED.created_at, token_hash = EXCLUDED.token_hash;
+
+-- name: GetUserByRiverTokenHash :one
+SELECT users.id, users.created_at, users.updated_at, users.name
+FROM river_tokens
+INNER JOIN users ON river_tokens.user_id = users.id
+WHERE river_tokens.token_hash = $1;
+
+-- name: DeleteRiverTokenByUserID :execrows
+DELETE FROM river_tokens
+WHERE user_id = $1;
@@ -0,0 +1,15 @@
+-- name: SearchPostsByUserID :many
+SELECT posts.id, posts.title, posts.url, posts.published_at, posts.created_at, feeds.name AS feed_name,
+    ts_rank(posts.search_vector, query)::real AS rank,
+    ts_headline('english', COALESCE(posts.title, '') || ' ' || COALESCE(posts.content, posts.description, ''), query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=25, MinWords=8')::text AS snippet
+FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feeds ON posts.feed_id = feeds.id,
+websearch_to_tsquery('english', sqlc.arg(query)) AS query
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+AND posts.search_vector @@ query
+AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+AND (sqlc.narg(since)::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) >= sqlc.narg(since))
+AND (sqlc.narg(until)::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg(until))
+ORDER BY rank DESC, COALESCE(posts.published_at, posts.created_at) DESC
+LIMIT sqlc.arg(result_limit);
@@ -0,0 +1,33 @@
+-- name: StarPost :execrows
+INSERT INTO post_stars (user_id, post_id, starred_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: UnstarPost :execrows
+DELETE FROM post_stars
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: GetStarredPostsByUserID :many
+SELECT posts.id, posts.title, posts.url, posts.description, posts.published_at, posts.author, feeds.name AS feed_name, feeds.url AS feed_url, post_stars.starred_at
+FROM post_stars
+INNER JOIN posts ON post_stars.post_id = posts.id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+WHERE post_stars.user_id = $1
+ORDER BY post_stars.starred_at DESC;
+
+-- name: GetStarredPostByID :one
+SELECT posts.*
+FROM post_stars
+INNER JOIN posts ON post_stars.post_id = posts.id
+WHERE post_stars.user_id = $1 AND post_stars.post_id = $2;
+
+-- name: GetStarredPostsByUrl :many
+SELECT posts.*
+FROM post_stars
+INNER JOIN posts ON post_stars.post_id = posts.id
+WHERE post_stars.user_id = $1 AND posts.url = $2
+ORDER BY posts.created_at ASC;
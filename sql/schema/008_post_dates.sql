@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE posts
+ALTER COLUMN published_at DROP NOT NULL,
+ADD COLUMN published_raw TEXT;
+
+-- +goose Down
+UPDATE posts
+SET published_at = created_at
+WHERE published_at IS NULL;
+
+ALTER TABLE posts
+ALTER COLUMN published_at SET NOT NULL,
+DROP COLUMN published_raw;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN site_url TEXT;
+
+ALTER TABLE feed_follows
+ADD COLUMN folder TEXT;
+
+-- +goose Down
+ALTER TABLE feed_follows
+DROP COLUMN folder;
+
+ALTER TABLE feeds
+DROP COLUMN site_url;
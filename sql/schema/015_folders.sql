@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE TABLE folders (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+ALTER TABLE feed_follows
+ADD COLUMN folder_id UUID REFERENCES folders(id) ON DELETE SET NULL;
+
+INSERT INTO folders (user_id, name)
+SELECT DISTINCT user_id, folder
+FROM feed_follows
+WHERE folder IS NOT NULL;
+
+UPDATE feed_follows
+SET folder_id = folders.id
+FROM folders
+WHERE folders.user_id = feed_follows.user_id
+AND folders.name = feed_follows.folder;
+
+ALTER TABLE feed_follows
+DROP COLUMN folder;
+
+-- +goose Down
+ALTER TABLE feed_follows
+ADD COLUMN folder TEXT;
+
+UPDATE feed_follows
+SET folder = folders.name
+FROM folders
+WHERE folders.id = feed_follows.folder_id;
+
+ALTER TABLE feed_follows
+DROP COLUMN folder_id;
+
+DROP TABLE folders;
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN categories TEXT[];
+
+CREATE TABLE filter_rules (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE,
+    match_type TEXT NOT NULL CHECK (match_type IN ('keyword', 'regex', 'author', 'category')),
+    pattern TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('hide', 'read'))
+);
+
+-- +goose Down
+DROP TABLE filter_rules;
+
+ALTER TABLE posts
+DROP COLUMN categories;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    last_used_at TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE api_keys;
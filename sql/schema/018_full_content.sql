@@ -0,0 +1,40 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN fetch_full_content BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE posts
+ADD COLUMN content TEXT;
+
+DROP INDEX posts_search_vector_idx;
+
+ALTER TABLE posts
+DROP COLUMN search_vector;
+
+ALTER TABLE posts
+ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('english', COALESCE(content, '')), 'C')
+) STORED;
+
+CREATE INDEX posts_search_vector_idx ON posts USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX posts_search_vector_idx;
+
+ALTER TABLE posts
+DROP COLUMN search_vector;
+
+ALTER TABLE posts
+ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX posts_search_vector_idx ON posts USING GIN (search_vector);
+
+ALTER TABLE posts
+DROP COLUMN content;
+
+ALTER TABLE feeds
+DROP COLUMN fetch_full_content;
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE attachments (
+    id UUID PRIMARY KEY NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    mime_type TEXT,
+    length BIGINT,
+    duration_seconds INTEGER,
+    UNIQUE (post_id, url)
+);
+
+CREATE TABLE attachment_downloads (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    attachment_id UUID NOT NULL REFERENCES attachments(id) ON DELETE CASCADE,
+    updated_at TIMESTAMP NOT NULL,
+    path TEXT NOT NULL,
+    bytes_downloaded BIGINT NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP,
+    last_error TEXT,
+    PRIMARY KEY (user_id, attachment_id)
+);
+
+-- +goose Down
+DROP TABLE attachment_downloads;
+DROP TABLE attachments;
@@ -0,0 +1,40 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN guid TEXT;
+
+UPDATE posts
+SET guid = url;
+
+ALTER TABLE posts
+ALTER COLUMN guid SET NOT NULL,
+DROP CONSTRAINT posts_url_key,
+ADD CONSTRAINT posts_feed_id_guid_key UNIQUE (feed_id, guid);
+
+CREATE INDEX posts_url_idx ON posts (url);
+
+ALTER TABLE feeds
+ADD COLUMN legacy_guids BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE feeds
+SET legacy_guids = true
+WHERE EXISTS (
+    SELECT 1
+    FROM posts
+    WHERE posts.feed_id = feeds.id
+);
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN legacy_guids;
+
+DELETE FROM posts
+USING posts AS earlier
+WHERE posts.url = earlier.url
+AND (posts.created_at, posts.id) > (earlier.created_at, earlier.id);
+
+DROP INDEX posts_url_idx;
+
+ALTER TABLE posts
+DROP CONSTRAINT posts_feed_id_guid_key,
+ADD CONSTRAINT posts_url_key UNIQUE (url),
+DROP COLUMN guid;
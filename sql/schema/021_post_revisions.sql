@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN content_hash TEXT,
+ADD COLUMN edited_at TIMESTAMP;
+
+CREATE TABLE post_revisions (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    title TEXT,
+    url TEXT NOT NULL,
+    description TEXT,
+    author TEXT,
+    categories TEXT[],
+    content_hash TEXT,
+    content TEXT
+);
+
+CREATE INDEX post_revisions_post_id_idx ON post_revisions (post_id, created_at);
+
+-- +goose Down
+DROP TABLE post_revisions;
+
+ALTER TABLE posts
+DROP COLUMN edited_at,
+DROP COLUMN content_hash;
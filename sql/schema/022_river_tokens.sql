@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE river_tokens (
+    user_id UUID PRIMARY KEY NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    token_hash TEXT NOT NULL UNIQUE
+);
+
+-- +goose Down
+DROP TABLE river_tokens;
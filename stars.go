@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lynn-Xy/bloggatog/internal/database"
+	"github.com/google/uuid"
+)
+
+type starredPost struct {
+	ID string `json:"id"`
+	Title string `json:"title"`
+	Url string `json:"url"`
+	Description string `json:"description,omitempty"`
+	Author string `json:"author,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	FeedName string `json:"feed_name"`
+	FeedUrl string `json:"feed_url"`
+	StarredAt time.Time `json:"starred_at"`
+}
+
+// findStarredPost looks a post up among the user's stars, which outlive
+// following the post's feed.
+func findStarredPost(ctx context.Context, s *state, user database.User, argument string) (database.Post, error) {
+	if id, err := uuid.Parse(argument); err == nil {
+		post, err := s.db.GetStarredPostByID(ctx, database.GetStarredPostByIDParams{
+			UserID: user.ID,
+			PostID: id,
+		})
+		if err == nil {
+			return post, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return database.Post{}, fmt.Errorf("error retrieving starred post from database: %v", err)
+		}
+	}
+	posts, err := s.db.GetStarredPostsByUrl(ctx, database.GetStarredPostsByUrlParams{
+		UserID: user.ID,
+		Url: argument,
+	})
+	if err != nil {
+		return database.Post{}, fmt.Errorf("error retrieving starred post from database: %v", err)
+	}
+	if len(posts) == 0 {
+		return database.Post{}, sql.ErrNoRows
+	}
+	if len(posts) > 1 {
+		ids := make([]string, len(posts))
+		for idx, post := range posts {
+			ids[idx] = post.ID.String()
+		}
+		return database.Post{}, &ambiguousError{fmt.Sprintf("%v matches several starred posts, use one of their ids instead: %v", argument, strings.Join(ids, ", "))}
+	}
+	return posts[0], nil
+}
+
+func starredPostTarget(ctx context.Context, s *state, user database.User, cmd command) (database.Post, error) {
+	if len(cmd.Arguments) < 1 {
+		return database.Post{}, errors.New("post id or url must be provided")
+	}
+	post, err := findStarredPost(ctx, s, user, cmd.Arguments[0])
+	if errors.Is(err, sql.ErrNoRows) {
+		post, err = findPost(ctx, s, user, cmd.Arguments[0])
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Post{}, fmt.Errorf("no post found matching: %v", cmd.Arguments[0])
+	}
+	return post, err
+}
+
+func HandlerStar(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	post, err := starredPostTarget(ctx, s, user, cmd)
+	if err != nil {
+		return err
+	}
+	params := database.StarPostParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		StarredAt: time.Now().UTC(),
+	}
+	_, err = s.db.StarPost(ctx, params)
+	if err != nil {
+		return fmt.Errorf("error starring post: %v", err)
+	}
+	fmt.Printf("starred: %v\n", post.Title.String)
+	return nil
+}
+
+func HandlerUnstar(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	post, err := starredPostTarget(ctx, s, user, cmd)
+	if err != nil {
+		return err
+	}
+	params := database.UnstarPostParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	}
+	removed, err := s.db.UnstarPost(ctx, params)
+	if err != nil {
+		return fmt.Errorf("error unstarring post: %v", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("post was not starred: %v", post.Title.String)
+	}
+	fmt.Printf("unstarred: %v\n", post.Title.String)
+	return nil
+}
+
+func HandlerStarred(s *state, cmd command, user database.User) error {
+	flags := flag.NewFlagSet("starred", flag.ContinueOnError)
+	export := flags.String("export", "", "write starred posts as json to this file")
+	args, err := parseArguments(flags, cmd.Arguments)
+	if err != nil {
+		return fmt.Errorf("error parsing starred flags: %v", err)
+	}
+	rows, err := s.db.GetStarredPostsByUserID(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving starred posts from database: %v", err)
+	}
+	if *export != "" {
+		return exportStarredPosts(*export, rows)
+	}
+	if len(args) > 0 {
+		limit, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("error parsing limit argument: %v", err)
+		}
+		if limit < len(rows) {
+			rows = rows[:limit]
+		}
+	}
+	for _, row := range rows {
+		fmt.Printf("* %v\n", row.Title.String)
+		fmt.Printf("  Id: %v\n", row.ID)
+		fmt.Printf("  Url: %v\n", row.Url)
+		fmt.Printf("  Feed: %v\n", row.FeedName.String)
+		fmt.Printf("  Starred: %v\n", row.StarredAt)
+	}
+	return nil
+}
+
+func exportStarredPosts(path string, rows []database.GetStarredPostsByUserIDRow) error {
+	posts := make([]starredPost, 0, len(rows))
+	for _, row := range rows {
+		post := starredPost{
+			ID: row.ID.String(),
+			Title: row.Title.String,
+			Url: row.Url,
+			Description: row.Description.String,
+			Author: row.Author.String,
+			FeedName: row.FeedName.String,
+			FeedUrl: row.FeedUrl,
+			StarredAt: row.StarredAt,
+		}
+		if row.PublishedAt.Valid {
+			post.PublishedAt = &row.PublishedAt.Time
+		}
+		posts = append(posts, post)
+	}
+	data, err := json.MarshalIndent(posts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling starred posts: %v", err)
+	}
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+	fmt.Printf("exported %v starred posts to %v\n", len(posts), path)
+	return nil
+}
@@ -0,0 +1,666 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Lynn-Xy/bloggatog/internal/database"
+	"github.com/google/uuid"
+	"golang.org/x/term"
+)
+
+const (
+	tuiPostLimit = 200
+	tuiFeedPaneWidth = 30
+)
+
+const (
+	keyUp = iota + 256
+	keyDown
+	keyLeft
+	keyRight
+	keyPageUp
+	keyPageDown
+	keyEnter
+	keyTab
+	keyQuit
+)
+
+type tuiFeed struct {
+	Name string
+	FeedID uuid.NullUUID
+}
+
+type tuiModel struct {
+	ctx context.Context
+	s *state
+	user database.User
+	options browseOptions
+	feeds []tuiFeed
+	feedIndex int
+	posts []database.GetXPostsByUserIDRow
+	postIndex int
+	postOffset int
+	focusPosts bool
+	previewScroll int
+	status string
+	width int
+	height int
+}
+
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},
+	{0x231A, 0x231B},
+	{0x2329, 0x232A},
+	{0x23E9, 0x23EC},
+	{0x23F0, 0x23F0},
+	{0x23F3, 0x23F3},
+	{0x25FD, 0x25FE},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267F, 0x267F},
+	{0x2693, 0x2693},
+	{0x26A1, 0x26A1},
+	{0x26AA, 0x26AB},
+	{0x26BD, 0x26BE},
+	{0x26C4, 0x26C5},
+	{0x26CE, 0x26CE},
+	{0x26D4, 0x26D4},
+	{0x26EA, 0x26EA},
+	{0x26F2, 0x26F3},
+	{0x26F5, 0x26F5},
+	{0x26FA, 0x26FA},
+	{0x26FD, 0x26FD},
+	{0x2705, 0x2705},
+	{0x270A, 0x270B},
+	{0x2728, 0x2728},
+	{0x274C, 0x274C},
+	{0x274E, 0x274E},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27B0, 0x27B0},
+	{0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C},
+	{0x2B50, 0x2B50},
+	{0x2B55, 0x2B55},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xA960, 0xA97F},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x16FE0, 0x16FE4},
+	{0x17000, 0x18CFF},
+	{0x1B000, 0x1B2FF},
+	{0x1F004, 0x1F004},
+	{0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E},
+	{0x1F191, 0x1F19A},
+	{0x1F200, 0x1F251},
+	{0x1F300, 0x1F64F},
+	{0x1F680, 0x1F6FF},
+	{0x1F7E0, 0x1F7EB},
+	{0x1F90C, 0x1F9FF},
+	{0x1FA70, 0x1FAFF},
+	{0x20000, 0x3FFFD},
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 || (r >= 0x7F && r <= 0x9F)
+}
+
+func stripControl(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			return ' '
+		case isControl(r):
+			return -1
+		}
+		return r
+	}, text)
+}
+
+func runeWidth(r rune) int {
+	if isControl(r) || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	for _, wide := range wideRanges {
+		if r < wide[0] {
+			break
+		}
+		if r <= wide[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+func displayWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+func cutWidth(text string, width int) (string, string) {
+	used := 0
+	for idx, r := range text {
+		w := runeWidth(r)
+		if used+w > width {
+			return text[:idx], text[idx:]
+		}
+		used += w
+	}
+	return text, ""
+}
+
+func wrapText(text string, width int) []string {
+	if width < 1 {
+		return nil
+	}
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := ""
+		for _, word := range words {
+			for displayWidth(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				head, rest := cutWidth(word, width)
+				if head == "" {
+					_, size := utf8.DecodeRuneInString(word)
+					head, rest = word[:size], word[size:]
+				}
+				lines = append(lines, head)
+				word = rest
+			}
+			switch {
+			case line == "":
+				line = word
+			case displayWidth(line)+1+displayWidth(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func fitText(text string, width int) string {
+	if width < 1 {
+		return ""
+	}
+	text = stripControl(text)
+	textWidth := displayWidth(text)
+	if textWidth > width {
+		if width == 1 {
+			return "…"
+		}
+		head, _ := cutWidth(text, width-1)
+		return head + "…" + strings.Repeat(" ", width-1-displayWidth(head))
+	}
+	return text + strings.Repeat(" ", width-textWidth)
+}
+
+// openInBrowser hands a post link to the system opener. Links come from
+// remote feeds, so anything but http and https is refused rather than
+// letting a feed launch file: or other protocol handlers.
+func openInBrowser(rawURL string) error {
+	link, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("error parsing post url: %v", err)
+	}
+	if link.Scheme != "http" && link.Scheme != "https" {
+		return fmt.Errorf("refusing to open non-http url: %v", stripControl(rawURL))
+	}
+	target := link.String()
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("error opening browser: %v", err)
+	}
+	go cmd.Wait()
+	return nil
+}
+
+func readKey(reader *bufio.Reader) (int, error) {
+	b, err := reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	switch b {
+	case 3:
+		return keyQuit, nil
+	case '\r', '\n':
+		return keyEnter, nil
+	case '\t':
+		return keyTab, nil
+	case 0x1b:
+		if reader.Buffered() == 0 {
+			return keyQuit, nil
+		}
+		next, _ := reader.ReadByte()
+		if next != '[' && next != 'O' {
+			return int(next), nil
+		}
+		code, _ := reader.ReadByte()
+		switch code {
+		case 'A':
+			return keyUp, nil
+		case 'B':
+			return keyDown, nil
+		case 'C':
+			return keyRight, nil
+		case 'D':
+			return keyLeft, nil
+		case '5', '6':
+			reader.ReadByte()
+			if code == '5' {
+				return keyPageUp, nil
+			}
+			return keyPageDown, nil
+		}
+		return 0, nil
+	}
+	return int(b), nil
+}
+
+func (m *tuiModel) loadFeeds() error {
+	follows, err := m.s.db.GetFollowedFeedsByUserID(m.ctx, m.user.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving feed_follows from database: %v", err)
+	}
+	m.feeds = []tuiFeed{{Name: "All feeds"}}
+	for _, follow := range follows {
+		name := follow.Name.String
+		if name == "" {
+			name = follow.Url
+		}
+		if follow.FolderName.String != "" {
+			name = follow.FolderName.String + "/" + name
+		}
+		m.feeds = append(m.feeds, tuiFeed{
+			Name: name,
+			FeedID: uuid.NullUUID{
+				UUID: follow.FeedID,
+				Valid: true,
+			},
+		})
+	}
+	if m.feedIndex >= len(m.feeds) {
+		m.feedIndex = len(m.feeds) - 1
+	}
+	return nil
+}
+
+func (m *tuiModel) loadPosts() error {
+	options := m.options
+	options.FeedID = m.feeds[m.feedIndex].FeedID
+	options.Limit = tuiPostLimit
+	posts, err := browsePosts(m.ctx, m.s, m.user, options)
+	if err != nil {
+		return err
+	}
+	m.posts = posts
+	if m.postIndex >= len(m.posts) {
+		m.postIndex = max(len(m.posts)-1, 0)
+	}
+	m.previewScroll = 0
+	return nil
+}
+
+func (m *tuiModel) selectedPost() *database.GetXPostsByUserIDRow {
+	if m.postIndex < 0 || m.postIndex >= len(m.posts) {
+		return nil
+	}
+	return &m.posts[m.postIndex]
+}
+
+func (m *tuiModel) toggleRead() error {
+	post := m.selectedPost()
+	if post == nil {
+		return nil
+	}
+	target := readTarget{PostID: post.ID}
+	if post.ReadAt.Valid {
+		_, err := markTargetUnread(m.ctx, m.s, m.user, target)
+		if err != nil {
+			return err
+		}
+		post.ReadAt = sql.NullTime{}
+		m.status = "marked unread"
+		return nil
+	}
+	_, err := markTargetRead(m.ctx, m.s, m.user, target)
+	if err != nil {
+		return err
+	}
+	post.ReadAt = sql.NullTime{
+		Time: time.Now().UTC(),
+		Valid: true,
+	}
+	m.status = "marked read"
+	return nil
+}
+
+func (m *tuiModel) toggleStar() error {
+	post := m.selectedPost()
+	if post == nil {
+		return nil
+	}
+	if post.StarredAt.Valid {
+		_, err := m.s.db.UnstarPost(m.ctx, database.UnstarPostParams{
+			UserID: m.user.ID,
+			PostID: post.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("error unstarring post: %v", err)
+		}
+		post.StarredAt = sql.NullTime{}
+		m.status = "unstarred"
+		return nil
+	}
+	now := time.Now().UTC()
+	_, err := m.s.db.StarPost(m.ctx, database.StarPostParams{
+		UserID: m.user.ID,
+		PostID: post.ID,
+		StarredAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("error starring post: %v", err)
+	}
+	post.StarredAt = sql.NullTime{
+		Time: now,
+		Valid: true,
+	}
+	m.status = "starred"
+	return nil
+}
+
+func (m *tuiModel) openPost() error {
+	post := m.selectedPost()
+	if post == nil {
+		return nil
+	}
+	err := openInBrowser(post.Url)
+	if err != nil {
+		return err
+	}
+	m.status = "opened " + post.Url
+	if !post.ReadAt.Valid {
+		err = m.toggleRead()
+	}
+	return err
+}
+
+func (m *tuiModel) move(delta int) error {
+	if !m.focusPosts {
+		index := min(max(m.feedIndex+delta, 0), len(m.feeds)-1)
+		if index == m.feedIndex {
+			return nil
+		}
+		m.feedIndex = index
+		m.postIndex = 0
+		m.postOffset = 0
+		return m.loadPosts()
+	}
+	if len(m.posts) == 0 {
+		return nil
+	}
+	index := min(max(m.postIndex+delta, 0), len(m.posts)-1)
+	if index != m.postIndex {
+		m.postIndex = index
+		m.previewScroll = 0
+	}
+	return nil
+}
+
+func (m *tuiModel) handleKey(key int) (bool, error) {
+	m.status = ""
+	switch key {
+	case keyQuit, 'q':
+		return true, nil
+	case keyUp, 'k':
+		return false, m.move(-1)
+	case keyDown, 'j':
+		return false, m.move(1)
+	case keyTab, keyLeft, keyRight, 'h', 'l':
+		m.focusPosts = !m.focusPosts
+	case keyPageDown, ' ', 'J':
+		m.previewScroll += max(m.height/4, 1)
+	case keyPageUp, 'K':
+		m.previewScroll = max(m.previewScroll-max(m.height/4, 1), 0)
+	case keyEnter:
+		if !m.focusPosts {
+			m.focusPosts = true
+			return false, nil
+		}
+		return false, m.openPost()
+	case 'o':
+		return false, m.openPost()
+	case 'r':
+		return false, m.toggleRead()
+	case 's':
+		return false, m.toggleStar()
+	case 'u':
+		m.options.UnreadOnly = !m.options.UnreadOnly
+		m.postIndex = 0
+		return false, m.loadPosts()
+	case 'g':
+		err := m.loadFeeds()
+		if err != nil {
+			return false, err
+		}
+		m.status = "refreshed"
+		return false, m.loadPosts()
+	}
+	return false, nil
+}
+
+func (m *tuiModel) previewLines(width int) []string {
+	post := m.selectedPost()
+	if post == nil {
+		return []string{"No posts to show."}
+	}
+	var lines []string
+	lines = append(lines, wrapText(post.Title.String, width)...)
+	meta := []string{post.FeedName.String}
+	if post.Author.String != "" {
+		meta = append(meta, post.Author.String)
+	}
+	date := post.CreatedAt
+	if post.PublishedAt.Valid {
+		date = post.PublishedAt.Time
+	}
+	meta = append(meta, date.Format("2006-01-02 15:04"))
+	if post.EditedAt.Valid {
+		meta = append(meta, "updated "+post.EditedAt.Time.Format("2006-01-02 15:04"))
+	}
+	lines = append(lines, wrapText(strings.Join(meta, " | "), width)...)
+	lines = append(lines, wrapText(post.Url, width)...)
+	lines = append(lines, "")
+	lines = append(lines, renderText(postBody(*post), width)...)
+	return lines
+}
+
+func (m *tuiModel) render(out *bufio.Writer) {
+	width, height := m.width, m.height
+	feedWidth := min(tuiFeedPaneWidth, width/4)
+	rightWidth := width - feedWidth - 1
+	bodyHeight := height - 2
+	listHeight := bodyHeight / 2
+	previewHeight := bodyHeight - listHeight - 1
+
+	if m.postIndex < m.postOffset {
+		m.postOffset = m.postIndex
+	}
+	if m.postIndex >= m.postOffset+listHeight {
+		m.postOffset = m.postIndex - listHeight + 1
+	}
+	feedOffset := 0
+	if m.feedIndex >= bodyHeight {
+		feedOffset = m.feedIndex - bodyHeight + 1
+	}
+	preview := m.previewLines(rightWidth)
+	if m.previewScroll > max(len(preview)-previewHeight, 0) {
+		m.previewScroll = max(len(preview)-previewHeight, 0)
+	}
+
+	out.WriteString("\x1b[H")
+	unread := "all posts"
+	if m.options.UnreadOnly {
+		unread = "unread only"
+	}
+	header := fmt.Sprintf(" gator | %v | %v | tab pane  j/k move  r read  s star  o open  u unread  g refresh  q quit", m.user.Name, unread)
+	out.WriteString("\x1b[7m" + fitText(header, width) + "\x1b[0m")
+	for row := 0; row < bodyHeight; row++ {
+		fmt.Fprintf(out, "\x1b[%d;1H", row+2)
+		feedLine := ""
+		if index := feedOffset + row; index < len(m.feeds) {
+			feedLine = " " + m.feeds[index].Name
+			if index == m.feedIndex {
+				out.WriteString(selectionStyle(!m.focusPosts))
+			}
+		}
+		out.WriteString(fitText(feedLine, feedWidth) + "\x1b[0m│")
+		switch {
+		case row < listHeight:
+			index := m.postOffset + row
+			if index >= len(m.posts) {
+				out.WriteString(fitText("", rightWidth))
+				continue
+			}
+			post := m.posts[index]
+			marker := "●"
+			if post.ReadAt.Valid || post.Muted {
+				marker = " "
+			}
+			star := " "
+			if post.StarredAt.Valid {
+				star = "★"
+			}
+			line := fmt.Sprintf("%v%v %v  (%v)", marker, star, post.Title.String, post.FeedName.String)
+			if index == m.postIndex {
+				out.WriteString(selectionStyle(m.focusPosts))
+			}
+			out.WriteString(fitText(line, rightWidth) + "\x1b[0m")
+		case row == listHeight:
+			out.WriteString(strings.Repeat("─", rightWidth))
+		default:
+			index := m.previewScroll + row - listHeight - 1
+			line := ""
+			if index < len(preview) {
+				line = preview[index]
+			}
+			if index == 0 {
+				out.WriteString("\x1b[1m")
+			}
+			out.WriteString(fitText(line, rightWidth) + "\x1b[0m")
+		}
+	}
+	status := m.status
+	if status == "" {
+		status = fmt.Sprintf("%v posts", len(m.posts))
+	}
+	fmt.Fprintf(out, "\x1b[%d;1H\x1b[2m%v\x1b[0m", height, fitText(" "+status, width))
+	out.Flush()
+}
+
+func selectionStyle(focused bool) string {
+	if focused {
+		return "\x1b[7m"
+	}
+	return "\x1b[1m"
+}
+
+func runTUI(s *state, user database.User, options browseOptions) error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("interactive mode requires a terminal")
+	}
+	m := &tuiModel{
+		ctx: context.Background(),
+		s: s,
+		user: user,
+		options: options,
+	}
+	err := m.loadFeeds()
+	if err != nil {
+		return err
+	}
+	err = m.loadPosts()
+	if err != nil {
+		return err
+	}
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("error switching terminal to raw mode: %v", err)
+	}
+	out := bufio.NewWriter(os.Stdout)
+	out.WriteString("\x1b[?1049h\x1b[?25l\x1b[2J")
+	defer func() {
+		out.WriteString("\x1b[0m\x1b[?25h\x1b[?1049l")
+		out.Flush()
+		term.Restore(fd, oldState)
+	}()
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil || width < 20 || height < 6 {
+			width, height = 80, 24
+		}
+		if width != m.width || height != m.height {
+			out.WriteString("\x1b[2J")
+		}
+		m.width, m.height = width, height
+		m.render(out)
+		key, err := readKey(reader)
+		if err != nil {
+			return fmt.Errorf("error reading keyboard input: %v", err)
+		}
+		quit, err := m.handleKey(key)
+		if err != nil {
+			m.status = err.Error()
+		}
+		if quit {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lynn-Xy/bloggatog/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	sessionCookieName = "gator_session"
+	webPageSize = 20
+	webExcerptLength = 400
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var webTemplates = map[string]*template.Template{
+	"login": parseWebTemplate("login.html"),
+	"posts": parseWebTemplate("posts.html"),
+	"feeds": parseWebTemplate("feeds.html"),
+}
+
+type webFeed struct {
+	Name string
+	Url string
+	Followed bool
+}
+
+type loginPage struct {
+	User database.User
+	Error string
+}
+
+type postsPage struct {
+	User database.User
+	Error string
+	Posts []database.GetXPostsByUserIDRow
+	Folders []database.Folder
+	Folder string
+	Query string
+	Unread bool
+	Page int
+	HasNext bool
+}
+
+type feedsPage struct {
+	User database.User
+	Error string
+	Following []database.GetFollowedFeedsByUserIDRow
+	Feeds []webFeed
+}
+
+func parseWebTemplate(page string) *template.Template {
+	funcs := template.FuncMap{
+		"excerpt": excerpt,
+		"postBody": postBody,
+		"sanitize": func(content string) template.HTML {
+			return template.HTML(sanitizeHTML(content, nil))
+		},
+		"postTime": func(publishedAt sql.NullTime, createdAt time.Time) string {
+			if publishedAt.Valid {
+				return publishedAt.Time.Format("2006-01-02 15:04")
+			}
+			return createdAt.Format("2006-01-02 15:04")
+		},
+		"inc": func(n int) int { return n + 1 },
+		"dec": func(n int) int { return n - 1 },
+	}
+	return template.Must(template.New("layout").Funcs(funcs).ParseFS(templateFS, "templates/layout.html", "templates/"+page))
+}
+
+func excerpt(description string) string {
+	text := html.UnescapeString(snippetTagPattern.ReplaceAllString(description, " "))
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) > webExcerptLength {
+		return string(runes[:webExcerptLength]) + "..."
+	}
+	return text
+}
+
+func (p postsPage) PageURL(page int) string {
+	values := url.Values{}
+	if p.Query != "" {
+		values.Set("q", p.Query)
+	}
+	if p.Folder != "" {
+		values.Set("folder", p.Folder)
+	}
+	if p.Unread {
+		values.Set("unread", "true")
+	}
+	if page > 1 {
+		values.Set("page", strconv.Itoa(page))
+	}
+	if len(values) == 0 {
+		return "/posts"
+	}
+	return "/posts?" + values.Encode()
+}
+
+func renderPage(w http.ResponseWriter, name string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := webTemplates[name].ExecuteTemplate(w, "layout", data)
+	if err != nil {
+		log.Printf("error rendering %v page: %v", name, err)
+	}
+}
+
+func redirectBack(w http.ResponseWriter, r *http.Request, fallback string) {
+	target := fallback
+	if referer, err := url.Parse(r.Referer()); err == nil && referer.Host == r.Host && referer.Path != "" {
+		target = referer.RequestURI()
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+func redirectWithError(w http.ResponseWriter, r *http.Request, path string, err error) {
+	http.Redirect(w, r, path+"?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+}
+
+func middlewareWebSession(s *state, handler apiHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookieName)
+		if err != nil || cookie.Value == "" {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		user, err := userForApiKey(r.Context(), s, cookie.Value)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.SetCookie(w, &http.Cookie{Name: sessionCookieName, Path: "/", MaxAge: -1})
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		if err != nil {
+			log.Printf("error retrieving api key from database: %v", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		handler(s, w, r, user)
+	}
+}
+
+func webGetLogin(w http.ResponseWriter, r *http.Request) {
+	renderPage(w, "login", loginPage{Error: r.URL.Query().Get("error")})
+}
+
+func webPostLogin(s *state) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.PostFormValue("key"))
+		if key == "" {
+			redirectWithError(w, r, "/login", errors.New("api key must be provided"))
+			return
+		}
+		_, err := userForApiKey(r.Context(), s, key)
+		if errors.Is(err, sql.ErrNoRows) {
+			redirectWithError(w, r, "/login", errors.New("invalid api key"))
+			return
+		}
+		if err != nil {
+			log.Printf("error retrieving api key from database: %v", err)
+			redirectWithError(w, r, "/login", errors.New("error checking api key"))
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name: sessionCookieName,
+			Value: key,
+			Path: "/",
+			HttpOnly: true,
+			Secure: r.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+		})
+		http.Redirect(w, r, "/posts", http.StatusSeeOther)
+	}
+}
+
+func webPostLogout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: sessionCookieName, Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func webGetPosts(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	query := r.URL.Query()
+	page := postsPage{
+		User: user,
+		Error: query.Get("error"),
+		Folder: query.Get("folder"),
+		Query: query.Get("q"),
+		Page: 1,
+	}
+	page.Unread, _ = strconv.ParseBool(query.Get("unread"))
+	if n, err := strconv.Atoi(query.Get("page")); err == nil && n > 1 {
+		page.Page = n
+	}
+	folders, err := s.db.GetFoldersByUserID(r.Context(), user.ID)
+	if err != nil {
+		page.Error = fmt.Sprintf("error retrieving folders from database: %v", err)
+	}
+	page.Folders = folders
+	posts, err := browsePosts(r.Context(), s, user, browseOptions{
+		UnreadOnly: page.Unread,
+		Folder: page.Folder,
+		Query: page.Query,
+		Limit: webPageSize + 1,
+		Offset: int32((page.Page - 1) * webPageSize),
+	})
+	if err != nil {
+		page.Error = err.Error()
+	}
+	if len(posts) > webPageSize {
+		page.HasNext = true
+		posts = posts[:webPageSize]
+	}
+	page.Posts = posts
+	renderPage(w, "posts", page)
+}
+
+func webMarkPost(read bool) apiHandler {
+	return func(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "invalid post id", http.StatusBadRequest)
+			return
+		}
+		target := readTarget{PostID: id}
+		if read {
+			_, err = markTargetRead(r.Context(), s, user, target)
+		} else {
+			_, err = markTargetUnread(r.Context(), s, user, target)
+		}
+		if err != nil {
+			redirectWithError(w, r, "/posts", err)
+			return
+		}
+		redirectBack(w, r, "/posts")
+	}
+}
+
+func webGetFeeds(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	page := feedsPage{
+		User: user,
+		Error: r.URL.Query().Get("error"),
+	}
+	following, err := s.db.GetFollowedFeedsByUserID(r.Context(), user.ID)
+	if err != nil {
+		page.Error = fmt.Sprintf("error retrieving feed_follows from database: %v", err)
+	}
+	page.Following = following
+	followed := make(map[string]bool)
+	for _, feed := range following {
+		followed[feed.Url] = true
+	}
+	feeds, err := s.db.GetAllFeeds(r.Context())
+	if err != nil {
+		page.Error = fmt.Sprintf("error retrieving feeds from database: %v", err)
+	}
+	for _, feed := range feeds {
+		page.Feeds = append(page.Feeds, webFeed{
+			Name: feed.Name.String,
+			Url: feed.Url,
+			Followed: followed[feed.Url],
+		})
+	}
+	renderPage(w, "feeds", page)
+}
+
+func webPostFeed(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	name := strings.TrimSpace(r.PostFormValue("name"))
+	feedURL := strings.TrimSpace(r.PostFormValue("url"))
+	if name == "" || feedURL == "" {
+		redirectWithError(w, r, "/feeds", errors.New("feed name and url must be provided"))
+		return
+	}
+	feedURL, err := discoverFeedURL(r.Context(), feedURL, false)
+	if err != nil {
+		redirectWithError(w, r, "/feeds", fmt.Errorf("error discovering feed: %v", err))
+		return
+	}
+	_, err = addFeed(r.Context(), s, user, name, feedURL)
+	if err != nil {
+		redirectWithError(w, r, "/feeds", err)
+		return
+	}
+	http.Redirect(w, r, "/feeds", http.StatusSeeOther)
+}
+
+func webPostFollow(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	_, err := followFeed(r.Context(), s, user, r.PostFormValue("url"))
+	if err != nil {
+		redirectWithError(w, r, "/feeds", err)
+		return
+	}
+	http.Redirect(w, r, "/feeds", http.StatusSeeOther)
+}
+
+func webPostUnfollow(s *state, w http.ResponseWriter, r *http.Request, user database.User) {
+	err := unfollowFeed(r.Context(), s, user, r.PostFormValue("url"))
+	if err != nil {
+		redirectWithError(w, r, "/feeds", err)
+		return
+	}
+	http.Redirect(w, r, "/feeds", http.StatusSeeOther)
+}
+
+func webRoutes(s *state, mux *http.ServeMux) {
+	mux.Handle("GET /{$}", http.RedirectHandler("/posts", http.StatusSeeOther))
+	mux.HandleFunc("GET /login", webGetLogin)
+	mux.HandleFunc("POST /login", webPostLogin(s))
+	mux.HandleFunc("POST /logout", webPostLogout)
+	mux.HandleFunc("GET /posts", middlewareWebSession(s, webGetPosts))
+	mux.HandleFunc("POST /posts/{id}/read", middlewareWebSession(s, webMarkPost(true)))
+	mux.HandleFunc("POST /posts/{id}/unread", middlewareWebSession(s, webMarkPost(false)))
+	mux.HandleFunc("GET /feeds", middlewareWebSession(s, webGetFeeds))
+	mux.HandleFunc("POST /feeds", middlewareWebSession(s, webPostFeed))
+	mux.HandleFunc("POST /follow", middlewareWebSession(s, webPostFollow))
+	mux.HandleFunc("POST /unfollow", middlewareWebSession(s, webPostUnfollow))
+}